@@ -4,21 +4,92 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/analyzer"
 	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/formatter"
+	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/metrics"
 	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/notifier"
+	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/schedule"
+	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/state"
 )
 
 const Version = "2.0.0"
 
+// enrichmentCachePath and rebuildCache are set once at startup from flags
+// and shared by every request handled by handleAnalysis.
+var (
+	enrichmentCachePath string
+	rebuildCache        bool
+	refreshEnrichment   string
+	notifyURLs          string
+	workloadProjects    string
+
+	// metricsCache memoizes handleMetrics' Analyze call behind
+	// METRICS_CACHE_TTL, since Prometheus scrapes /metrics far more often
+	// than an analysis needs re-running.
+	metricsCache = &metrics.Cache{}
+
+	// anomalyTracker classifies each Analyze pass's anomalies as new,
+	// ongoing or resolved against its Store's persisted history, so
+	// handleAnalysis can report resolutions instead of just going quiet.
+	anomalyTracker = state.NewTracker(newAnomalyStateStore())
+
+	// dispatchAnalyzer backs handleDispatch. Unlike handleAnalysis's
+	// per-request analyzer, it's built once and never closed, since
+	// dispatched jobs keep running on Analyzer.runDispatchLoop in the
+	// background after the HTTP request that queued them returns.
+	dispatchAnalyzer   *analyzer.Analyzer
+	dispatchAnalyzerMu sync.Mutex
+)
+
+// newAnomalyStateStore selects a state.Store backend from
+// ANOMALY_STATE_BACKEND ("file", the default; "gcs"; or "firestore"),
+// mirroring how notifier.NewManager picks notifiers by env var presence.
+func newAnomalyStateStore() state.Store {
+	switch getEnvOrDefault("ANOMALY_STATE_BACKEND", "file") {
+	case "gcs":
+		return &state.GCSStore{
+			Bucket: os.Getenv("ANOMALY_STATE_BUCKET"),
+			Object: getEnvOrDefault("ANOMALY_STATE_OBJECT", "gcbdr-anomaly-state.json"),
+		}
+	case "firestore":
+		return &state.FirestoreStore{
+			ProjectID:  os.Getenv("ANOMALY_STATE_PROJECT"),
+			Collection: getEnvOrDefault("ANOMALY_STATE_COLLECTION", "gcbdr-anomaly-state"),
+		}
+	default:
+		return &state.FileStore{
+			Path: getEnvOrDefault("ANOMALY_STATE_PATH", "/tmp/gcbdr-monitor/anomaly-state.json"),
+		}
+	}
+}
+
 func main() {
+	flag.StringVar(&enrichmentCachePath, "enrichment-cache", getEnvOrDefault("ENRICHMENT_CACHE_PATH", "/tmp/gcbdr-monitor/enrichment-cache.json"), "path to the persistent resource-size enrichment cache")
+	flag.BoolVar(&rebuildCache, "rebuild-cache", false, "ignore any existing enrichment cache entries and re-fetch every resource size")
+	flag.StringVar(&refreshEnrichment, "refresh-enrichment", "", "comma-separated resource names to force a fresh size lookup for, bypassing any cached (including negative) entry")
+	flag.StringVar(&notifyURLs, "notify-url", getEnvOrDefault("NOTIFY_URLS", ""), "comma-separated Shoutrrr-style notification target URLs (discord://, telegram://, slack://, teams://, pushover://, smtp://, script://, https://)")
+	flag.StringVar(&workloadProjects, "workload-projects", getEnvOrDefault("WORKLOAD_PROJECTS", ""), "comma-separated project IDs to fan log fetches out over, in addition to GOOGLE_CLOUD_PROJECT")
+	flag.Parse()
+
+	if ttl := os.Getenv("METRICS_CACHE_TTL"); ttl != "" {
+		if parsed, err := time.ParseDuration(ttl); err == nil {
+			metricsCache.TTL = parsed
+		} else {
+			log.Printf("Warning: invalid METRICS_CACHE_TTL %q: %v", ttl, err)
+		}
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -26,6 +97,14 @@ func main() {
 
 	http.HandleFunc("/", handleAnalysis)
 	http.HandleFunc("/health", handleHealth)
+	http.HandleFunc("/metrics", handleMetrics)
+	http.HandleFunc("/api/anomalies/", handleAck)
+	http.HandleFunc("/api/dispatch", handleDispatch)
+	http.HandleFunc("/api/dispatch/", handleDispatch)
+
+	if cronExpr := os.Getenv("DIGEST_CRON"); cronExpr != "" {
+		go runDigestScheduler(cronExpr)
+	}
 
 	log.Printf("GCBDR Monitor v%s starting on port %s", Version, port)
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
@@ -33,11 +112,202 @@ func main() {
 	}
 }
 
+func getEnvOrDefault(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
+
+// parseWorkloadProjects splits the -workload-projects/WORKLOAD_PROJECTS
+// flag into the []string analyzer.New expects, dropping blank entries.
+func parseWorkloadProjects() []string {
+	var projects []string
+	for _, p := range strings.Split(workloadProjects, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			projects = append(projects, p)
+		}
+	}
+	return projects
+}
+
 func handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "OK")
 }
 
+// handleMetrics exports Prometheus-format metrics derived from the most
+// recent analysis, reusing (and not re-running more often than
+// metricsCache.TTL allows) the same analyzer.Analyze pass handleAnalysis
+// uses, so a Prometheus scrape doesn't hammer the BigQuery/Cloud Asset
+// APIs on every poll.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if projectID == "" {
+		http.Error(w, "GOOGLE_CLOUD_PROJECT environment variable not set", http.StatusInternalServerError)
+		return
+	}
+
+	days := 7
+	if d := os.Getenv("METRICS_DAYS"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil {
+			days = parsed
+		}
+	}
+
+	result, err := metricsCache.Get(func() (*analyzer.AnalysisResult, error) {
+		ctx := context.Background()
+		a, err := analyzer.New(projectID, days, parseWorkloadProjects())
+		if err != nil {
+			return nil, err
+		}
+		defer a.Close()
+
+		a.RebuildCache = rebuildCache
+		if err := a.SetEnrichmentCache(enrichmentCachePath); err != nil {
+			log.Printf("metrics: failed to load enrichment cache from %s: %v", enrichmentCachePath, err)
+		}
+		return a.Analyze(ctx, "", "all")
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("metrics analysis error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(metrics.Render(result))
+}
+
+// handleAck acks an anomaly fingerprint, suppressing its notifications
+// until ack_until, the way silencing an Alertmanager alert does. Expects
+// POST /api/anomalies/{fingerprint}/ack?ack_until=<RFC3339 timestamp>.
+func handleAck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fingerprint, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/anomalies/"), "/")
+	if !ok || action != "ack" || fingerprint == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	ackUntilParam := r.URL.Query().Get("ack_until")
+	if ackUntilParam == "" {
+		http.Error(w, "ack_until query parameter is required (RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	ackUntil, err := time.Parse(time.RFC3339, ackUntilParam)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid ack_until: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := anomalyTracker.Ack(fingerprint, ackUntil); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getDispatchAnalyzer lazily builds the long-lived Analyzer handleDispatch
+// serves Dispatch/Status/Result requests against, building it once since
+// GOOGLE_CLOUD_PROJECT doesn't change for the life of the process.
+func getDispatchAnalyzer(projectID string) (*analyzer.Analyzer, error) {
+	dispatchAnalyzerMu.Lock()
+	defer dispatchAnalyzerMu.Unlock()
+
+	if dispatchAnalyzer != nil {
+		return dispatchAnalyzer, nil
+	}
+
+	a, err := analyzer.New(projectID, 7, parseWorkloadProjects())
+	if err != nil {
+		return nil, err
+	}
+	a.RebuildCache = rebuildCache
+	if err := a.SetEnrichmentCache(enrichmentCachePath); err != nil {
+		log.Printf("dispatch: failed to load enrichment cache from %s: %v", enrichmentCachePath, err)
+	}
+	dispatchAnalyzer = a
+	return a, nil
+}
+
+// handleDispatch serves the on-demand Dispatch/Status/Result API a Cloud
+// Run / Pub/Sub front end would drive:
+//
+//	POST /api/dispatch                 body: analyzer.DispatchRequest JSON -> {"job_id": "..."}
+//	GET  /api/dispatch/{jobID}         -> analyzer.DispatchStatus JSON
+//	GET  /api/dispatch/{jobID}/result  -> AnalysisResult JSON, once the job has completed
+func handleDispatch(w http.ResponseWriter, r *http.Request) {
+	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if projectID == "" {
+		http.Error(w, "GOOGLE_CLOUD_PROJECT environment variable not set", http.StatusInternalServerError)
+		return
+	}
+
+	a, err := getDispatchAnalyzer(projectID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create analyzer: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/dispatch"), "/")
+
+	if path == "" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req analyzer.DispatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		jobID, err := a.Dispatch(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"job_id": string(jobID)})
+		return
+	}
+
+	jobID, rest, _ := strings.Cut(path, "/")
+	switch rest {
+	case "":
+		status, err := a.Status(analyzer.JobID(jobID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	case "result":
+		result, err := a.Result(analyzer.JobID(jobID))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		output, err := formatter.FormatJSON(result)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Format error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(output)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
 func handleAnalysis(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 
@@ -71,13 +341,25 @@ func handleAnalysis(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Starting GCBDR analysis v%s for project %s with %d days history", Version, projectID, days)
 
 	// Create analyzer
-	a, err := analyzer.New(projectID, days)
+	a, err := analyzer.New(projectID, days, parseWorkloadProjects())
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create analyzer: %v", err), http.StatusInternalServerError)
 		return
 	}
 	defer a.Close()
 
+	a.RebuildCache = rebuildCache
+	if err := a.SetEnrichmentCache(enrichmentCachePath); err != nil {
+		log.Printf("Warning: failed to load enrichment cache from %s: %v", enrichmentCachePath, err)
+	}
+	for _, name := range strings.Split(refreshEnrichment, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		a.InvalidateEnrichment(name)
+	}
+
 	// Run analysis
 	result, err := a.Analyze(ctx, filterName, sourceType)
 	if err != nil {
@@ -85,11 +367,24 @@ func handleAnalysis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Send notifications if anomalies found
-	if len(result.Anomalies) > 0 && shouldNotify {
-		log.Printf("Sending notifications for %d anomalies...", len(result.Anomalies))
-		nm := notifier.NewManager(projectID)
-		nm.SendNotifications(result.Anomalies)
+	// Classify anomalies against prior state and notify on new/ongoing
+	// (unacked) and newly-resolved fingerprints alike -- run even when
+	// result.Anomalies is empty, since that's exactly the pass that
+	// notices a previously-firing anomaly has cleared.
+	if shouldNotify {
+		classified, err := anomalyTracker.Classify(result.Anomalies)
+		if err != nil {
+			log.Printf("Warning: failed to classify anomaly state: %v", err)
+		} else {
+			var urls []string
+			for _, u := range strings.Split(notifyURLs, ",") {
+				if u = strings.TrimSpace(u); u != "" {
+					urls = append(urls, u)
+				}
+			}
+			nm := notifier.NewManager(projectID, urls)
+			nm.SendClassified(classified)
+		}
 	}
 
 	// Format output
@@ -116,3 +411,78 @@ func handleAnalysis(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", contentType)
 	w.Write(output)
 }
+
+// runDigestScheduler runs runDigestOnce on the schedule described by
+// cronExpr (a standard 5-field cron expression, DIGEST_CRON), e.g.
+// "0 9 * * *" for a daily 9am digest. It loops forever and returns only
+// when cronExpr is malformed: that error is logged once and stops the loop,
+// since every subsequent NextAfter call would fail the same way.
+func runDigestScheduler(cronExpr string) {
+	digestDays := 7
+	if d := os.Getenv("DIGEST_DAYS"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil {
+			digestDays = parsed
+		}
+	}
+
+	for {
+		next, err := schedule.NextAfter(cronExpr, time.Now())
+		if err != nil {
+			log.Printf("digest scheduler: invalid DIGEST_CRON %q: %v", cronExpr, err)
+			return
+		}
+		log.Printf("digest scheduler: next digest at %s", next)
+		time.Sleep(time.Until(next))
+		runDigestOnce(digestDays)
+	}
+}
+
+// runDigestOnce runs one Analyze pass over the last days days and emails
+// the rendered digest to DIGEST_RECIPIENTS, regardless of whether any
+// anomalies were found.
+func runDigestOnce(days int) {
+	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if projectID == "" {
+		log.Printf("digest scheduler: GOOGLE_CLOUD_PROJECT not set, skipping digest")
+		return
+	}
+
+	recipients := strings.Split(os.Getenv("DIGEST_RECIPIENTS"), ",")
+	if len(recipients) == 0 || recipients[0] == "" {
+		log.Printf("digest scheduler: DIGEST_RECIPIENTS not set, skipping digest")
+		return
+	}
+
+	log.Printf("digest scheduler: running %d-day digest for project %s", days, projectID)
+
+	ctx := context.Background()
+	a, err := analyzer.New(projectID, days, parseWorkloadProjects())
+	if err != nil {
+		log.Printf("digest scheduler: failed to create analyzer: %v", err)
+		return
+	}
+	defer a.Close()
+
+	a.RebuildCache = rebuildCache
+	if err := a.SetEnrichmentCache(enrichmentCachePath); err != nil {
+		log.Printf("digest scheduler: failed to load enrichment cache from %s: %v", enrichmentCachePath, err)
+	}
+
+	result, err := a.Analyze(ctx, "", "all")
+	if err != nil {
+		log.Printf("digest scheduler: analysis error: %v", err)
+		return
+	}
+
+	dn := &notifier.DigestNotifier{
+		Host:       getEnvOrDefault("SMTP_HOST", ""),
+		Port:       getEnvOrDefault("SMTP_PORT", "587"),
+		User:       os.Getenv("SMTP_USER"),
+		Password:   os.Getenv("SMTP_PASSWORD"),
+		Sender:     os.Getenv("EMAIL_SENDER"),
+		Recipients: recipients,
+	}
+	if err := dn.SendDigest(result, days); err != nil {
+		log.Printf("digest scheduler: failed to send digest: %v", err)
+	}
+}
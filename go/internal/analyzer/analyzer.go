@@ -10,18 +10,15 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/logging"
 	"cloud.google.com/go/logging/logadmin"
 	"google.golang.org/api/iterator"
 	"google.golang.org/protobuf/types/known/structpb"
-    "google.golang.org/protobuf/proto"
 
-    compute_v1 "cloud.google.com/go/compute/apiv1"
     computepb "cloud.google.com/go/compute/apiv1/computepb"
-    sqladmin "google.golang.org/api/sqladmin/v1"
-    "google.golang.org/api/option"
 )
 
 // JobData represents a parsed backup job.
@@ -52,14 +49,38 @@ type ResourceStats struct {
 	StdDevGiB             float64 `json:"stddev_gib"`
 	AvgDurationSeconds    float64 `json:"avg_duration_seconds"`
 	StdDevDuration        float64 `json:"stddev_duration"`
+
+	// MedianGiB / MADGiB are the robust (outlier-resistant) center and
+	// spread of GiBTransferred across the window, used by MADDetector
+	// instead of AvgGiB/StdDevGiB.
+	MedianGiB float64 `json:"median_gib"`
+	MADGiB    float64 `json:"mad_gib"`
+
+	// MedianDurationSeconds / MADDurationSeconds are the same robust
+	// center/spread, computed over DurationSeconds instead of GiBTransferred.
+	MedianDurationSeconds float64 `json:"median_duration_seconds"`
+	MADDurationSeconds    float64 `json:"mad_duration_seconds"`
+
+	// EWMAGiB / EWMSDGiB are the exponentially-weighted moving average and
+	// standard deviation of GiBTransferred as of the most recent job in the
+	// window, reported for dashboards. No Detector reads them:
+	// SeasonalEWMADetector keeps its own per-bucket EWMA state.
+	EWMAGiB  float64 `json:"ewma_gib"`
+	EWMSDGiB float64 `json:"ewmsd_gib"`
 }
 
 // Anomaly represents a detected anomaly.
 type Anomaly struct {
-	JobID              string   `json:"job_id"`
-	Resource           string   `json:"resource"`
-	Date               string   `json:"date"`
-	Time               string   `json:"time"`
+	JobID    string `json:"job_id"`
+	Resource string `json:"resource"`
+	Date     string `json:"date"`
+	Time     string `json:"time"` // job start time, in LocalLocation
+
+	// FinishTime is Time plus DurationSeconds, also in LocalLocation, so
+	// on-call engineers can see the job's wall-clock window without doing
+	// the arithmetic themselves.
+	FinishTime string `json:"finish_time"`
+
 	GiBTransferred     float64  `json:"gib_transferred"`
 	AvgGiB             float64  `json:"avg_gib"`
 	DurationSeconds    float64  `json:"duration_seconds"`
@@ -87,6 +108,12 @@ type AnalysisResult struct {
 	ApplianceWorkloads WorkloadResult  `json:"appliance_workloads"`
 	Anomalies          []Anomaly       `json:"anomalies"`
 	DailyBaselines     []DailyBaseline `json:"daily_baselines"`
+
+	// Footprints rolls all jobs (vault and appliance) up by project, with
+	// WeightedTotal weighted by each job's resource size in GB, so operators
+	// can see which project's backup footprint dominates without paging
+	// through per-resource stats.
+	Footprints         []Footprint     `json:"footprints"`
     DebugMessages      []string        `json:"debug_messages,omitempty"`
 }
 
@@ -119,12 +146,168 @@ type Analyzer struct {
 	WorkloadProjects []string
 	client           *logadmin.Client
     DebugLog         []string
+    // debugMu guards DebugLog, which LogDebug appends to from the
+    // per-project goroutines Analyze's ForEachJob fan-out spawns. Held by
+    // pointer (like the dispatcher field below) rather than as a
+    // plain sync.Mutex field, so runDispatchJob's `scoped := *a` value
+    // copy shares the same lock instead of cloning an independently stuck
+    // one -- the same embedded-mutex hazard dispatch.go's dispatchManager
+    // comment already calls out avoiding.
+    debugMu          *sync.Mutex
+
+    // MaxConcurrentFetches bounds how many workload projects are queried for
+    // vault/appliance/GCB logs at once. Defaults to 8 in New.
+    MaxConcurrentFetches int
+
+    // EnrichmentCacheTTL controls how long a cached resource size is trusted
+    // before calculateStatistics re-fetches it. When zero, each entry gets
+    // enrichmentTTLForType's per-type default (DiskCloudSQLEnrichmentTTL for
+    // disks and Cloud SQL, DefaultEnrichmentCacheTTL otherwise).
+    EnrichmentCacheTTL time.Duration
+
+    // RebuildCache, when true, discards any loaded enrichment cache entries
+    // and re-fetches every resource size for this run. Wired to a
+    // --rebuild-cache CLI flag by callers.
+    RebuildCache bool
+
+    enrichmentCache *EnrichmentCache
+
+    // EnrichmentConcurrency bounds how many resource size lookups
+    // enrichConcurrent runs at once against the GCE/CloudSQL APIs. Defaults
+    // to DefaultEnrichmentConcurrency in New.
+    EnrichmentConcurrency int
+
+    // DispatchConfig describes the payload shapes Dispatch accepts.
+    DispatchConfig DispatchConfig
+
+    // DispatchJobTTL controls how long a finished dispatch job's state and
+    // result are retained before eviction. Defaults to DefaultDispatchJobTTL
+    // when zero.
+    DispatchJobTTL time.Duration
+
+    dispatcher *dispatchManager
+
+    // Detectors selects which anomaly Detector implementations
+    // detectAnomalies runs over each resource's job series. Defaults to
+    // defaultDetectors() (MAD + seasonal EWMA, for size and duration) when
+    // empty; set this to []Detector{ZScoreDetector{...}} or a custom mix to
+    // opt into a different anomaly policy.
+    Detectors []Detector
+
+    // FetcherRegistry selects which ResourceFetchers enrichResourceSizes
+    // consults to resolve a resource's size on a cache miss. Defaults to
+    // defaultFetcherRegistry (GCE instance/disk, Cloud SQL, Filestore, GCS,
+    // BigQuery) built fresh per call when nil; tests and third parties can
+    // set this to register mock or additional fetchers without touching the
+    // analyzer core.
+    FetcherRegistry *FetcherRegistry
+}
+
+// SetEnrichmentCache points the analyzer at a disk-backed enrichment cache,
+// loading existing entries from path (or starting empty if it doesn't exist
+// yet, or if RebuildCache is set). Analyze flushes the cache back to path
+// when it finishes.
+func (a *Analyzer) SetEnrichmentCache(path string) error {
+    if a.RebuildCache {
+        c := NewEnrichmentCache()
+        c.path = path
+        a.enrichmentCache = c
+        return nil
+    }
+
+    c, err := LoadEnrichmentCache(path)
+    if err != nil {
+        return err
+    }
+    a.enrichmentCache = c
+    return nil
+}
+
+// InvalidateEnrichment drops any cached size (positive or negative) for the
+// named resource, forcing the next calculateStatistics run to re-fetch it
+// from the GCE/CloudSQL APIs instead of trusting the cache. Wired to a
+// --refresh-enrichment CLI flag by callers.
+func (a *Analyzer) InvalidateEnrichment(resourceName string) {
+    a.enrichmentCache.Invalidate(resourceName)
+}
+
+// ForEachJob runs fn for every index in [0, n) across a bounded pool of
+// workers, cancelling the shared context as soon as fn returns the first
+// error. It blocks until all outstanding work has stopped.
+func ForEachJob(ctx context.Context, n, maxConcurrency int, fn func(ctx context.Context, idx int) error) error {
+    if n == 0 {
+        return nil
+    }
+    if maxConcurrency <= 0 {
+        maxConcurrency = 1
+    }
+    if maxConcurrency > n {
+        maxConcurrency = n
+    }
+
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    indexes := make(chan int)
+    go func() {
+        defer close(indexes)
+        for i := 0; i < n; i++ {
+            select {
+            case indexes <- i:
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+
+    var (
+        wg       sync.WaitGroup
+        mu       sync.Mutex
+        firstErr error
+    )
+
+    wg.Add(maxConcurrency)
+    for w := 0; w < maxConcurrency; w++ {
+        go func() {
+            defer wg.Done()
+            for idx := range indexes {
+                if err := fn(ctx, idx); err != nil {
+                    mu.Lock()
+                    if firstErr == nil {
+                        firstErr = err
+                        cancel()
+                    }
+                    mu.Unlock()
+                }
+            }
+        }()
+    }
+
+    wg.Wait()
+    return firstErr
 }
 
 func (a *Analyzer) LogDebug(format string, v ...interface{}) {
     msg := fmt.Sprintf(format, v...)
     log.Println(msg)
+    if a.debugMu == nil {
+        a.debugMu = &sync.Mutex{}
+    }
+    a.debugMu.Lock()
     a.DebugLog = append(a.DebugLog, msg)
+    a.debugMu.Unlock()
+}
+
+// debugLogCount returns the number of debug messages logged so far. Reads
+// DebugLog's length under debugMu since it's appended to concurrently by
+// LogDebug from the per-project fan-out goroutines.
+func (a *Analyzer) debugLogCount() int {
+    if a.debugMu == nil {
+        return len(a.DebugLog)
+    }
+    a.debugMu.Lock()
+    defer a.debugMu.Unlock()
+    return len(a.DebugLog)
 }
 
 // New creates a new Analyzer.
@@ -135,10 +318,13 @@ func New(projectID string, days int, workloadProjects []string) (*Analyzer, erro
 		return nil, fmt.Errorf("failed to create logging client: %w", err)
 	}
 	return &Analyzer{
-		ProjectID:        projectID,
-		Days:             days,
-		WorkloadProjects: workloadProjects,
-		client:           client,
+		ProjectID:             projectID,
+		Days:                  days,
+		WorkloadProjects:      workloadProjects,
+		client:                client,
+		MaxConcurrentFetches:  8,
+		EnrichmentConcurrency: DefaultEnrichmentConcurrency,
+		debugMu:               &sync.Mutex{},
 	}, nil
 }
 
@@ -166,72 +352,118 @@ func (a *Analyzer) Analyze(ctx context.Context, filterName, sourceType string) (
         a.LogDebug(fmt.Sprintf("WARNING: Unknown source_type '%s', skipping logic blocks!", sourceType))
     }
 
-	// Collect all jobs
-	var allVaultJobs, allApplianceJobs []JobData
-	if sourceType == "all" || sourceType == "vault" {
-		if jobs, err := a.fetchAndParseVaultLogs(ctx); err == nil {
-			allVaultJobs = filterJobs(jobs, filterName)
-			stats := a.calculateStatistics(allVaultJobs, a.Days)
-			result.VaultWorkloads.ResourceStats = stats
-			result.Summary.TotalVaultJobs = len(allVaultJobs)
-			anomalies := detectAnomalies(allVaultJobs, stats)
-			result.Anomalies = append(result.Anomalies, anomalies...)
-		} else {
-			log.Printf("Warning: failed to fetch vault logs: %v", err)
-		}
+	// Collect logs for every workload project concurrently. Each project's
+	// vault/appliance/GCB fetches run as one ForEachJob unit so a slow or
+	// failing project can't stall the others.
+	projects := a.WorkloadProjects
+	if len(projects) == 0 {
+		projects = []string{a.ProjectID}
 	}
 
-	if sourceType == "all" || sourceType == "appliance" {
-		// Fetch GCB Logs for enrichment
-		gcbJobs, err := a.fetchAndParseGCBJobLogs(ctx)
-		if err != nil {
-			log.Printf("Warning: failed to fetch GCB logs: %v", err)
+	maxConcurrency := a.MaxConcurrentFetches
+	if maxConcurrency <= 0 {
+		maxConcurrency = 8
+	}
+
+	var (
+		fetchMu          sync.Mutex
+		allVaultJobs     []JobData
+		allApplianceJobs []JobData
+		allGCBJobs       = make(map[string]JobData)
+	)
+
+	if err := ForEachJob(ctx, len(projects), maxConcurrency, func(ctx context.Context, idx int) error {
+		projectID := projects[idx]
+
+		if sourceType == "all" || sourceType == "vault" {
+			jobs, err := a.fetchAndParseVaultLogs(ctx, projectID)
+			fetchMu.Lock()
+			if err != nil {
+				result.DebugMessages = append(result.DebugMessages, fmt.Sprintf("Warning: failed to fetch vault logs for project %s: %v", projectID, err))
+			} else {
+				allVaultJobs = append(allVaultJobs, jobs...)
+			}
+			fetchMu.Unlock()
 		}
 
-		if jobs, err := a.fetchAndParseApplianceLogs(ctx); err == nil {
-			// Enrich appliance jobs
-            var enrichedCount int
-            var missingCount int
-			for i := range jobs {
-				job := &jobs[i]
-				// Try to match with GCB job
-				// Appliance job has "Job_..." as JobID usually
-				if gcbData, ok := gcbJobs[job.JobID]; ok {
-					if job.TotalResourceSizeBytes == 0 && gcbData.TotalResourceSizeBytes > 0 {
-						job.TotalResourceSizeBytes = gcbData.TotalResourceSizeBytes
-						job.TotalResourceSizeBytes = gcbData.TotalResourceSizeBytes
-						a.LogDebug("DEBUG: Enriched job %s with size %d from GCB", job.JobID, job.TotalResourceSizeBytes)
-                        enrichedCount++
-					}
-					if job.GiBTransferred == 0 && gcbData.GiBTransferred > 0 {
-						job.GiBTransferred = gcbData.GiBTransferred
-						a.LogDebug("DEBUG: Enriched job %s with transferred %.2f GiB from GCB", job.JobID, job.GiBTransferred)
-					}
-				} else {
-                    if missingCount < 5 {
-                        a.LogDebug("DEBUG: No GCB Match for Appliance Job: %s", job.JobID)
-                        missingCount++
-                    }
-                }
+		if sourceType == "all" || sourceType == "appliance" {
+			gcbJobs, gcbErr := a.fetchAndParseGCBJobLogs(ctx, projectID)
+			if gcbErr != nil {
+				fetchMu.Lock()
+				result.DebugMessages = append(result.DebugMessages, fmt.Sprintf("Warning: failed to fetch GCB logs for project %s: %v", projectID, gcbErr))
+				fetchMu.Unlock()
+			}
+
+			jobs, err := a.fetchAndParseApplianceLogs(ctx, projectID)
+			if err != nil {
+				fetchMu.Lock()
+				result.DebugMessages = append(result.DebugMessages, fmt.Sprintf("Warning: failed to fetch appliance logs for project %s: %v", projectID, err))
+				fetchMu.Unlock()
+				return nil
+			}
+
+			fetchMu.Lock()
+			for id, gcbJob := range gcbJobs {
+				allGCBJobs[id] = gcbJob
+			}
+			allApplianceJobs = append(allApplianceJobs, jobs...)
+			fetchMu.Unlock()
+		}
+
+		return nil
+	}); err != nil {
+		a.LogDebug("WARNING: project fetch fan-out returned an error: %v", err)
+	}
+
+	if sourceType == "all" || sourceType == "vault" {
+		allVaultJobs = filterJobs(allVaultJobs, filterName)
+		stats := a.calculateStatistics(ctx, allVaultJobs, a.Days)
+		result.VaultWorkloads.ResourceStats = stats
+		result.Summary.TotalVaultJobs = len(allVaultJobs)
+		anomalies := a.detectAnomalies(allVaultJobs, stats)
+		result.Anomalies = append(result.Anomalies, anomalies...)
+	}
+
+	if sourceType == "all" || sourceType == "appliance" {
+		// Enrich appliance jobs with data pulled from the merged GCB job map.
+		var enrichedCount int
+		var missingCount int
+		for i := range allApplianceJobs {
+			job := &allApplianceJobs[i]
+			// Try to match with GCB job
+			// Appliance job has "Job_..." as JobID usually
+			if gcbData, ok := allGCBJobs[job.JobID]; ok {
+				if job.TotalResourceSizeBytes == 0 && gcbData.TotalResourceSizeBytes > 0 {
+					job.TotalResourceSizeBytes = gcbData.TotalResourceSizeBytes
+					a.LogDebug("DEBUG: Enriched job %s with size %d from GCB", job.JobID, job.TotalResourceSizeBytes)
+					enrichedCount++
+				}
+				if job.GiBTransferred == 0 && gcbData.GiBTransferred > 0 {
+					job.GiBTransferred = gcbData.GiBTransferred
+					a.LogDebug("DEBUG: Enriched job %s with transferred %.2f GiB from GCB", job.JobID, job.GiBTransferred)
+				}
+			} else {
+				if missingCount < 5 {
+					a.LogDebug("DEBUG: No GCB Match for Appliance Job: %s", job.JobID)
+					missingCount++
+				}
 			}
-            a.LogDebug("DEBUG: Enriched %d appliance jobs with GCB data", enrichedCount)
-
-			allApplianceJobs = filterJobs(jobs, filterName)
-			// Calculate stats
-			stats := a.calculateStatistics(allApplianceJobs, a.Days)
-			result.ApplianceWorkloads.ResourceStats = stats
-			result.Summary.TotalApplianceJobs = len(allApplianceJobs)
-			anomalies := detectAnomalies(allApplianceJobs, stats)
-			result.Anomalies = append(result.Anomalies, anomalies...)
-		} else {
-			a.LogDebug("Warning: failed to fetch appliance logs: %v", err)
 		}
+		a.LogDebug("DEBUG: Enriched %d appliance jobs with GCB data", enrichedCount)
+
+		allApplianceJobs = filterJobs(allApplianceJobs, filterName)
+		stats := a.calculateStatistics(ctx, allApplianceJobs, a.Days)
+		result.ApplianceWorkloads.ResourceStats = stats
+		result.Summary.TotalApplianceJobs = len(allApplianceJobs)
+		anomalies := a.detectAnomalies(allApplianceJobs, stats)
+		result.Anomalies = append(result.Anomalies, anomalies...)
 	}
 
 	allJobs := append(allVaultJobs, allApplianceJobs...)
 	// Combine stats for daily baseline calculation
 	allStats := append(result.VaultWorkloads.ResourceStats, result.ApplianceWorkloads.ResourceStats...)
 	result.DailyBaselines = calculateDailyBaselines(allJobs, result.Anomalies, allStats, a.Days)
+	result.Footprints = a.Footprints(allJobs, result.Anomalies, GroupByProject, WeightResourceSizeGB)
 
 	// Calculate Summary Metrics
 	var totalSizeGB, dailyChangeGB, dailyChangePct float64
@@ -277,25 +509,51 @@ func (a *Analyzer) Analyze(ctx context.Context, filterName, sourceType string) (
     result.Summary.ZeroSizeVaultCount = zeroSizeVaultCount
     result.Summary.TotalVaultResourceCount = len(result.VaultWorkloads.ResourceStats)
 	result.Summary.AnomalyCount = len(result.Anomalies)
+
+    if a.enrichmentCache != nil {
+        hits, misses, stale, negHits := a.enrichmentCache.Stats()
+        a.LogDebug("DEBUG: enrichment cache hits=%d misses=%d stale=%d negative_hits=%d", hits, misses, stale, negHits)
+        if err := a.enrichmentCache.Save(); err != nil {
+            a.LogDebug("WARNING: failed to flush enrichment cache: %v", err)
+        }
+    }
+
 	return result, nil
 }
 
-func (a *Analyzer) fetchAndParseVaultLogs(ctx context.Context) ([]JobData, error) {
-	filter := fmt.Sprintf(
+// vaultLogFilter, applianceLogFilter and gcbLogFilter build the logadmin
+// filter strings for each log source, shared by the slice-based fetch*
+// methods and their fetchLogsStream-based counterparts.
+func (a *Analyzer) vaultLogFilter(projectID string) string {
+	return fmt.Sprintf(
 		`logName="projects/%s/logs/backupdr.googleapis.com%%2Fbdr_backup_restore_jobs" AND timestamp >= "%s"`,
-		a.ProjectID,
+		projectID,
 		time.Now().AddDate(0, 0, -a.Days).Format(time.RFC3339),
 	)
-	return a.fetchLogs(ctx, filter, "vault")
 }
 
-func (a *Analyzer) fetchAndParseApplianceLogs(ctx context.Context) ([]JobData, error) {
-	filter := fmt.Sprintf(
+func (a *Analyzer) applianceLogFilter(projectID string) string {
+	return fmt.Sprintf(
 		`logName="projects/%s/logs/backupdr.googleapis.com%%2Fbackup_recovery_appliance_events" AND jsonPayload.eventId=44003 AND timestamp >= "%s"`,
-		a.ProjectID,
+		projectID,
+		time.Now().AddDate(0, 0, -a.Days).Format(time.RFC3339),
+	)
+}
+
+func (a *Analyzer) gcbLogFilter(projectID string) string {
+	return fmt.Sprintf(
+		`logName="projects/%s/logs/backupdr.googleapis.com%%2Fgcb_backup_recovery_jobs" AND timestamp >= "%s"`,
+		projectID,
 		time.Now().AddDate(0, 0, -a.Days).Format(time.RFC3339),
 	)
-	return a.fetchLogs(ctx, filter, "appliance")
+}
+
+func (a *Analyzer) fetchAndParseVaultLogs(ctx context.Context, projectID string) ([]JobData, error) {
+	return a.fetchLogs(ctx, a.vaultLogFilter(projectID), "vault")
+}
+
+func (a *Analyzer) fetchAndParseApplianceLogs(ctx context.Context, projectID string) ([]JobData, error) {
+	return a.fetchLogs(ctx, a.applianceLogFilter(projectID), "appliance")
 }
 
 func (a *Analyzer) fetchLogs(ctx context.Context, filter, source string) ([]JobData, error) {
@@ -481,7 +739,7 @@ func (a *Analyzer) parseLogEntry(entry *logging.Entry, source string) *JobData {
 	} else if source == "gcb" {
 		// GCB Job Logs Parsing
         // Debug: Log keys for first GCB job to verify schema
-        if a.DebugLog != nil && len(a.DebugLog) < 20 {
+        if a.debugLogCount() < 20 {
              keys := make([]string, 0, len(payload))
              for k := range payload {
                  keys = append(keys, k)
@@ -596,12 +854,8 @@ func (a *Analyzer) parseLogEntry(entry *logging.Entry, source string) *JobData {
 	return job
 }
 
-func (a *Analyzer) fetchAndParseGCBJobLogs(ctx context.Context) (map[string]JobData, error) {
-	filter := fmt.Sprintf(
-		`logName="projects/%s/logs/backupdr.googleapis.com%%2Fgcb_backup_recovery_jobs" AND timestamp >= "%s"`,
-		a.ProjectID,
-		time.Now().AddDate(0, 0, -a.Days).Format(time.RFC3339),
-	)
+func (a *Analyzer) fetchAndParseGCBJobLogs(ctx context.Context, projectID string) (map[string]JobData, error) {
+	filter := a.gcbLogFilter(projectID)
 
 	// We use fetchLogs but need to process them into a map
 	jobs, err := a.fetchLogs(ctx, filter, "gcb")
@@ -631,25 +885,31 @@ func filterJobs(jobs []JobData, pattern string) []JobData {
 	}
 
 	var filtered []JobData
-	pattern = strings.ToLower(pattern)
-
-	// Check if pattern contains wildcards
-	hasWildcard := strings.ContainsAny(pattern, "*?")
-
 	for _, job := range jobs {
-		name := strings.ToLower(job.ResourceName)
-		if hasWildcard {
-			matched, _ := matchWildcard(pattern, name)
-			if matched {
-				filtered = append(filtered, job)
-			}
-		} else if strings.Contains(name, pattern) {
+		if matchesResourceFilter(job.ResourceName, pattern) {
 			filtered = append(filtered, job)
 		}
 	}
 	return filtered
 }
 
+// matchesResourceFilter reports whether resourceName matches pattern, which
+// may be a plain case-insensitive substring or a '*'/'?' wildcard pattern.
+func matchesResourceFilter(resourceName, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	name := strings.ToLower(resourceName)
+	pattern = strings.ToLower(pattern)
+
+	if strings.ContainsAny(pattern, "*?") {
+		matched, _ := matchWildcard(pattern, name)
+		return matched
+	}
+	return strings.Contains(name, pattern)
+}
+
 func matchWildcard(pattern, s string) (bool, error) {
 	// Convert wildcard pattern to regex
 	regexPattern := "^" + regexp.QuoteMeta(pattern) + "$"
@@ -663,18 +923,25 @@ func matchWildcard(pattern, s string) (bool, error) {
 // Enable enrichment
 // We need the project ID for fetching details.
 // Modifying signature to accept projectID
-func (a *Analyzer) calculateStatistics(jobs []JobData, days int) []ResourceStats {
+func (a *Analyzer) calculateStatistics(ctx context.Context, jobs []JobData, days int) []ResourceStats {
 	// Group by resource
 	byResource := make(map[string][]JobData)
 	for _, job := range jobs {
 		byResource[job.ResourceName] = append(byResource[job.ResourceName], job)
 	}
 
-    ctx := context.Background()
-    // Cache for enrichment to avoid repetitive calls
+    // In-run cache for enrichment calls, used as a fallback when no
+    // persistent a.enrichmentCache has been configured via SetEnrichmentCache.
     enrichmentCache := make(map[string]int64)
 
 	var stats []ResourceStats
+	// pending collects the resources calculateStatistics couldn't resolve a
+	// size for from jobs/caches, keyed by ResourceName so the results of the
+	// concurrent enrichment pass below can be patched back into the right
+	// stats entry (by index into statsIndex).
+	var pending []enrichmentRequest
+	statsIndex := make(map[string]int)
+
 	for name, rjobs := range byResource {
 		if len(rjobs) == 0 {
 			continue
@@ -691,39 +958,29 @@ func (a *Analyzer) calculateStatistics(jobs []JobData, days int) []ResourceStats
 				maxTotalBytes = j.TotalResourceSizeBytes
 			}
 		}
-        
-        // Enrichment: If maxTotalBytes is 0, try to fetch from API
-        if maxTotalBytes == 0 {
-            resourceType := strings.ToLower(rjobs[0].ResourceType)
-            
-            // Determine project ID to use (prefer from job, fallback to global)
-            useProjectID := a.ProjectID
-            if rjobs[0].ProjectID != "" {
-                useProjectID = rjobs[0].ProjectID
-            }
 
-            if val, ok := enrichmentCache[name]; ok {
-                maxTotalBytes = val
-            } else {
-                var sizeBytes int64
-                if strings.Contains(resourceType, "vmware") {
-                    // Skip enrichment for VMware VMs
-                    sizeBytes = 0
-                } else if strings.Contains(resourceType, "gce") || strings.Contains(resourceType, "compute") || strings.Contains(resourceType, "vm") {
-                    sizeBytes = a.fetchGCEInstanceDetails(ctx, useProjectID, name)
-                } else if strings.Contains(resourceType, "disk") {
-                    sizeBytes = a.fetchGCEDiskDetails(ctx, useProjectID, name)
-                } else if strings.Contains(resourceType, "cloud sql") {
-                    sizeBytes = a.fetchCloudSQLDetails(ctx, useProjectID, name)
-                }
-                
-                if sizeBytes > 0 {
-                    maxTotalBytes = sizeBytes
-                    enrichmentCache[name] = sizeBytes
-                }
-            }
-        }
-        
+		// Enrichment: if maxTotalBytes is 0, try the caches, queuing an API
+		// lookup (run concurrently below) if neither has it.
+		resourceType := strings.ToLower(rjobs[0].ResourceType)
+		useProjectID := a.ProjectID
+		if rjobs[0].ProjectID != "" {
+			useProjectID = rjobs[0].ProjectID
+		}
+
+		if maxTotalBytes == 0 {
+			if cached, found, negative := a.enrichmentCache.Get(useProjectID, resourceType, name); found && !negative {
+				maxTotalBytes = cached
+			} else if val, ok := enrichmentCache[name]; ok {
+				maxTotalBytes = val
+			} else if !found {
+				pending = append(pending, enrichmentRequest{
+					name:         name,
+					resourceType: resourceType,
+					projectID:    useProjectID,
+				})
+			}
+		}
+
 		avgGiB := totalGiB / float64(len(rjobs))
 		avgDuration := totalDuration / float64(len(rjobs))
 
@@ -748,6 +1005,29 @@ func (a *Analyzer) calculateStatistics(jobs []JobData, days int) []ResourceStats
 			dailyChangePct = (dailyChangeGB / totalResourceSizeGB) * 100
 		}
 
+		// Robust center/spread (median + MAD) so a single huge backup doesn't
+		// drag the mean/stddev baseline up and mask later real anomalies.
+		// Computed for both size and duration so MADDetector/SeasonalEWMADetector
+		// can apply the same treatment to each.
+		gibValues := make([]float64, len(rjobs))
+		durationValues := make([]float64, len(rjobs))
+		timeOrdered := make([]JobData, len(rjobs))
+		copy(timeOrdered, rjobs)
+		sort.Slice(timeOrdered, func(i, j int) bool {
+			return timeOrdered[i].StartTime.Before(timeOrdered[j].StartTime)
+		})
+		for i, j := range timeOrdered {
+			gibValues[i] = j.GiBTransferred
+			durationValues[i] = j.DurationSeconds
+		}
+		medianGiB, madGiB := medianAndMAD(gibValues)
+		medianDuration, madDuration := medianAndMAD(durationValues)
+
+		// EWMA baseline as of the most recent job, seeded from the oldest
+		// job in the window so short series still get a usable state.
+		ewmaGiB, ewmsdGiB := ewmaState(gibValues, ewmaAlpha)
+
+		statsIndex[name] = len(stats)
 		stats = append(stats, ResourceStats{
 			ResourceName:          name,
 			ResourceType:          rjobs[0].ResourceType,
@@ -760,9 +1040,19 @@ func (a *Analyzer) calculateStatistics(jobs []JobData, days int) []ResourceStats
 			StdDevGiB:             stdDevGiB,
 			AvgDurationSeconds:    avgDuration,
 			StdDevDuration:        stdDevDuration,
+			MedianGiB:             medianGiB,
+			MADGiB:                madGiB,
+			MedianDurationSeconds: medianDuration,
+			MADDurationSeconds:    madDuration,
+			EWMAGiB:               ewmaGiB,
+			EWMSDGiB:              ewmsdGiB,
 		})
 	}
 
+	if len(pending) > 0 {
+		a.enrichResourceSizes(ctx, pending, stats, statsIndex, days, enrichmentCache)
+	}
+
 	// Sort by resource name
 	sort.Slice(stats, func(i, j int) bool {
 		return stats[i].ResourceName < stats[j].ResourceName
@@ -771,9 +1061,135 @@ func (a *Analyzer) calculateStatistics(jobs []JobData, days int) []ResourceStats
 	return stats
 }
 
-func detectAnomalies(jobs []JobData, stats []ResourceStats) []Anomaly {
-	const zScoreThreshold = 3.0
-	const dropOffThreshold = 0.1
+// enrichResourceSizes resolves every pending resource's size via a bounded
+// worker pool sharing one set of GCE/CloudSQL REST clients (enrichConcurrent),
+// instead of the old sequential fetchGCE*/fetchCloudSQL* calls that each
+// constructed their own client. Resolved sizes are patched back into stats
+// in place (via statsIndex) along with the TotalResourceSizeGB/
+// CurrentDailyChangePct figures that depend on them.
+func (a *Analyzer) enrichResourceSizes(ctx context.Context, pending []enrichmentRequest, stats []ResourceStats, statsIndex map[string]int, days int, enrichmentCache map[string]int64) {
+	registry := a.FetcherRegistry
+	if registry == nil {
+		// Only build live GCE/CloudSQL/etc. REST clients when no override
+		// was supplied, so a caller-set FetcherRegistry (e.g. tests'
+		// mocks) never depends on live GCP credentials being available.
+		clients, err := newSharedEnrichmentClients(ctx)
+		if err != nil {
+			a.LogDebug("WARNING: failed to create shared enrichment clients, skipping %d resource size lookups: %v", len(pending), err)
+			return
+		}
+		defer clients.Close()
+
+		registry = defaultFetcherRegistry(clients, a.WorkloadProjects)
+	}
+
+	sizes, err := a.enrichConcurrent(ctx, pending, func(ctx context.Context, req enrichmentRequest) (int64, error) {
+		return a.fetchSize(ctx, registry, req)
+	})
+	if err != nil {
+		a.LogDebug("WARNING: concurrent resource size enrichment returned an error: %v", err)
+	}
+
+	for _, req := range pending {
+		sizeBytes, ok := sizes[req.name]
+		if !ok || sizeBytes == 0 {
+			a.enrichmentCache.SetNegative(req.projectID, req.resourceType, req.name, 0)
+			continue
+		}
+
+		a.enrichmentCache.Set(req.projectID, req.resourceType, req.name, sizeBytes, a.EnrichmentCacheTTL)
+		enrichmentCache[req.name] = sizeBytes
+
+		idx, ok := statsIndex[req.name]
+		if !ok {
+			continue
+		}
+		s := &stats[idx]
+		s.TotalResourceSizeGB = float64(sizeBytes) / (1024 * 1024 * 1024)
+		if s.TotalResourceSizeGB > 0 {
+			s.CurrentDailyChangePct = (s.CurrentDailyChangeGB / s.TotalResourceSizeGB) * 100
+		}
+	}
+}
+
+// Thresholds for the anomaly detectors. madThreshold follows the common
+// "modified Z-score" convention (Iglewicz & Hoaglin); ewmaZThreshold/
+// ewmaAlpha follow the classic EWMA control-chart parameterization.
+const (
+	zScoreThreshold       = 3.0
+	dropOffThreshold      = 0.1
+	madThreshold          = 3.5
+	ewmaZThreshold        = 3.0
+	ewmaAlpha             = 0.3
+	minJobsForRobustStats = 5
+
+	// minSeasonalSpread floors SeasonalEWMADetector's running MAD-like
+	// bucket spread, so a bucket whose history so far is perfectly flat
+	// (mad == 0) doesn't permanently disable detection for that bucket.
+	minSeasonalSpread = 1e-6
+)
+
+// medianAndMAD computes the median and the raw (unscaled) median absolute
+// deviation of values. MADDetector's modified Z-score applies the 0.6745
+// consistency constant to it.
+func medianAndMAD(values []float64) (median, mad float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median = medianOfSorted(sorted)
+
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+	sort.Float64s(deviations)
+	mad = medianOfSorted(deviations)
+
+	return median, mad
+}
+
+func medianOfSorted(sorted []float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// ewmaState walks a time-ordered series of values and returns the final
+// EWMA mean/stddev, seeded from the first value so short series still
+// produce a usable baseline.
+func ewmaState(values []float64, alpha float64) (mu, sigma float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	mu = values[0]
+	var sigma2 float64
+	for _, v := range values[1:] {
+		newSigma2 := alpha*math.Pow(v-mu, 2) + (1-alpha)*sigma2
+		mu = alpha*v + (1-alpha)*mu
+		sigma2 = newSigma2
+	}
+
+	return mu, math.Sqrt(sigma2)
+}
+
+// detectAnomalies runs a.Detectors (or defaultDetectors() if unset) over
+// every resource's time-ordered job series, plus the one built-in check
+// (Size Drop-off) that isn't expressed as a pluggable Detector since it
+// doesn't need any baseline state.
+func (a *Analyzer) detectAnomalies(jobs []JobData, stats []ResourceStats) []Anomaly {
+	detectors := a.Detectors
+	if len(detectors) == 0 {
+		detectors = defaultDetectors()
+	}
 
 	// Create stats lookup
 	statsMap := make(map[string]ResourceStats)
@@ -781,50 +1197,70 @@ func detectAnomalies(jobs []JobData, stats []ResourceStats) []Anomaly {
 		statsMap[s.ResourceName] = s
 	}
 
-	var anomalies []Anomaly
+	// Group jobs by resource, time-ordered, so seasonal/EWMA detectors can
+	// walk through each resource's series the way it would have been
+	// observed.
+	byResource := make(map[string][]JobData)
 	for _, job := range jobs {
-		s, ok := statsMap[job.ResourceName]
+		byResource[job.ResourceName] = append(byResource[job.ResourceName], job)
+	}
+	for name, rjobs := range byResource {
+		sort.Slice(rjobs, func(i, j int) bool {
+			return rjobs[i].StartTime.Before(rjobs[j].StartTime)
+		})
+		byResource[name] = rjobs
+	}
+
+	var anomalies []Anomaly
+	for name, rjobs := range byResource {
+		s, ok := statsMap[name]
 		if !ok {
 			continue
 		}
 
-		var reasons []string
+		state := newDetectorState()
+
+		for _, job := range rjobs {
+			var reasons []string
 
-		// Size spike (Z-score)
-		if s.StdDevGiB > 0 {
-			zScore := (job.GiBTransferred - s.AvgGiB) / s.StdDevGiB
-			if zScore > zScoreThreshold {
-				reasons = append(reasons, fmt.Sprintf("Size Spike (Z=%.1f)", zScore))
+			for _, d := range detectors {
+				for _, r := range d.Detect(job, s, state) {
+					reasons = append(reasons, d.Name()+": "+r)
+				}
 			}
-		}
 
-		// Size drop-off
-		if s.AvgGiB > 1.0 && job.GiBTransferred < s.AvgGiB*dropOffThreshold {
-			reasons = append(reasons, "Size Drop-off")
-		}
+			// Size drop-off
+			if s.AvgGiB > 1.0 && job.GiBTransferred < s.AvgGiB*dropOffThreshold {
+				reasons = append(reasons, "Size Drop-off")
+			}
 
-		// Duration spike
-		if s.StdDevDuration > 0 {
-			durationZ := (job.DurationSeconds - s.AvgDurationSeconds) / s.StdDevDuration
-			if durationZ > zScoreThreshold {
-				reasons = append(reasons, fmt.Sprintf("Duration Spike (Z=%.1f)", durationZ))
+			if len(reasons) > 0 {
+				localStart := job.StartTime.In(LocalLocation())
+				localFinish := localStart.Add(time.Duration(job.DurationSeconds) * time.Second)
+				anomalies = append(anomalies, Anomaly{
+					JobID:              job.JobID,
+					Resource:           job.ResourceName,
+					Date:               localStart.Format("2006-01-02"),
+					Time:               localStart.Format("15:04:05"),
+					FinishTime:         localFinish.Format("15:04:05"),
+					GiBTransferred:     job.GiBTransferred,
+					AvgGiB:             s.AvgGiB,
+					DurationSeconds:    job.DurationSeconds,
+					AvgDurationSeconds: s.AvgDurationSeconds,
+					Reasons:            reasons,
+				})
 			}
 		}
+	}
 
-		if len(reasons) > 0 {
-			anomalies = append(anomalies, Anomaly{
-				JobID:              job.JobID,
-				Resource:           job.ResourceName,
-				Date:               job.StartTime.Format("2006-01-02"),
-				Time:               job.StartTime.Format("15:04:05"),
-				GiBTransferred:     job.GiBTransferred,
-				AvgGiB:             s.AvgGiB,
-				DurationSeconds:    job.DurationSeconds,
-				AvgDurationSeconds: s.AvgDurationSeconds,
-				Reasons:            reasons,
-			})
+	// Keep output order stable (date, then resource) now that anomalies are
+	// collected per-resource rather than in job-slice order.
+	sort.SliceStable(anomalies, func(i, j int) bool {
+		if anomalies[i].Date != anomalies[j].Date {
+			return anomalies[i].Date < anomalies[j].Date
 		}
-	}
+		return anomalies[i].Resource < anomalies[j].Resource
+	})
 
 	return anomalies
 }
@@ -883,6 +1319,7 @@ func calculateDailyBaselines(jobs []JobData, anomalies []Anomaly, stats []Resour
 
 		todayResources := make(map[string]bool)
 		todayResourceSizes := make(map[string]int64)
+		todayTransferredBytes := make(map[string]int64)
 		var modifiedBytes int64
 
 		for _, job := range daysJobs {
@@ -899,7 +1336,9 @@ func calculateDailyBaselines(jobs []JobData, anomalies []Anomaly, stats []Resour
 				todayResourceSizes[job.ResourceName] = size
 			}
 
-			modifiedBytes += int64(job.GiBTransferred * 1024 * 1024 * 1024)
+			transferredBytes := int64(job.GiBTransferred * 1024 * 1024 * 1024)
+			todayTransferredBytes[job.ResourceName] += transferredBytes
+			modifiedBytes += transferredBytes
 		}
 
 		// Suspicious bytes (from anomalies on this date)
@@ -915,7 +1354,7 @@ func calculateDailyBaselines(jobs []JobData, anomalies []Anomaly, stats []Resour
 		var newResourceCount int
 		for r := range todayResources {
 			if !firstDayResources[r] {
-				newBytes += todayResourceSizes[r]
+				newBytes += todayTransferredBytes[r]
 				newResourceCount++
 			}
 		}
@@ -975,98 +1414,8 @@ func calculateDailyBaselines(jobs []JobData, anomalies []Anomaly, stats []Resour
 	return baselines
 }
 
-// Helper to fetch GCE Instance Details
-func (a *Analyzer) fetchGCEInstanceDetails(ctx context.Context, projectID, resourceName string) int64 {
-	// Regex to extract project, zone, instance
-	// matches: projects/{project}/zones/{zone}/instances/{instance}
-	re := regexp.MustCompile(`projects/([^/]+)/zones/([^/]+)/instances/([^/]+)`)
-
-	targetProject := projectID
-	var targetZone, instanceName string
-
-	if match := re.FindStringSubmatch(resourceName); match != nil {
-		targetProject = match[1]
-		targetZone = match[2]
-		instanceName = match[3]
-	} else {
-		// Fallback: try to find just project/instance or just instance
-		instanceName = resourceName
-		if strings.Contains(resourceName, "/") {
-			parts := strings.Split(resourceName, "/")
-			instanceName = parts[len(parts)-1]
-		}
-	}
-
-	log.Printf("DEBUG: Fetching GCE details for %s (Initial Proj=%s)", instanceName, targetProject)
-
-	// Helper to try fetching from a specific project
-	tryFetch := func(pid, zone, inst string) int64 {
-		c, err := compute_v1.NewInstancesRESTClient(ctx)
-		if err != nil {
-			log.Printf("WARN: Failed to create instances client: %v", err)
-			return 0
-		}
-		defer c.Close()
-
-		// If zone is known, try direct get
-		if zone != "" {
-			req := &computepb.GetInstanceRequest{
-				Project:  pid,
-				Zone:     zone,
-				Instance: inst,
-			}
-			resp, err := c.Get(ctx, req)
-			if err == nil {
-				return calculateDiskSize(resp)
-			}
-		}
-
-		// Fallback to AggregatedList
-		req := &computepb.AggregatedListInstancesRequest{
-			Project: pid,
-			Filter:  proto.String(fmt.Sprintf("name = %s", inst)),
-		}
-
-		it := c.AggregatedList(ctx, req)
-		for {
-			pair, err := it.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				// common to fail if permissions missing or deprecated API
-				return 0
-			}
-			if pair.Value.Instances != nil {
-				for _, instance := range pair.Value.Instances {
-					if instance.GetName() == inst {
-						return calculateDiskSize(instance)
-					}
-				}
-			}
-		}
-		return 0
-	}
-
-	// 1. Try initial target project
-	if size := tryFetch(targetProject, targetZone, instanceName); size > 0 {
-		return size
-	}
-
-	// 2. Try workload projects if different
-	for _, wp := range a.WorkloadProjects {
-		if wp == targetProject {
-			continue
-		}
-		if size := tryFetch(wp, targetZone, instanceName); size > 0 {
-			log.Printf("DEBUG: Found %s in workload project %s", instanceName, wp)
-			return size
-		}
-	}
-
-	return 0
-}
-
+// calculateDiskSize sums an instance's attached disk sizes, used by the
+// shared-client GCE fetchers in enrichment_fetch.go.
 func calculateDiskSize(instance *computepb.Instance) int64 {
     var totalGB int64
     for _, disk := range instance.Disks {
@@ -1075,119 +1424,6 @@ func calculateDiskSize(instance *computepb.Instance) int64 {
     return totalGB * 1024 * 1024 * 1024 // Return bytes
 }
 
-// Helper for Persistent Disks
-func (a *Analyzer) fetchGCEDiskDetails(ctx context.Context, projectID, resourceName string) int64 {
-	// projects/{project}/zones/{zone}/disks/{disk}
-	re := regexp.MustCompile(`projects/([^/]+)/zones/([^/]+)/disks/([^/]+)`)
-
-	targetProject := projectID
-	var targetZone, diskName string
-
-	if match := re.FindStringSubmatch(resourceName); match != nil {
-		targetProject = match[1]
-		targetZone = match[2]
-		diskName = match[3]
-	} else {
-		return 0
-	}
-
-	tryFetch := func(pid, zone, dName string) int64 {
-		c, err := compute_v1.NewDisksRESTClient(ctx)
-		if err != nil {
-			log.Printf("WARN: Failed to create disks client: %v", err)
-			return 0
-		}
-		defer c.Close()
-
-		req := &computepb.GetDiskRequest{
-			Project: pid,
-			Zone:    zone,
-			Disk:    dName,
-		}
-		resp, err := c.Get(ctx, req)
-		if err == nil {
-			return resp.GetSizeGb() * 1024 * 1024 * 1024
-		}
-		return 0
-	}
-
-	// 1. Initial attempt
-	if size := tryFetch(targetProject, targetZone, diskName); size > 0 {
-		return size
-	}
-
-	// 2. Fallback to workload projects
-	for _, wp := range a.WorkloadProjects {
-		if wp == targetProject {
-			continue
-		}
-		if size := tryFetch(wp, targetZone, diskName); size > 0 {
-			log.Printf("DEBUG: Found disk %s in workload project %s", diskName, wp)
-			return size
-		}
-	}
-
-	return 0
-}
-
-// Helper for CloudSQL
-func (a *Analyzer) fetchCloudSQLDetails(ctx context.Context, projectID, resourceName string) int64 {
-	// projects/{project}/instances/{instance}
-	re := regexp.MustCompile(`projects/([^/]+)/instances/([^/]+)`)
-
-	targetProject := projectID
-	var instanceName string
-
-	if match := re.FindStringSubmatch(resourceName); match != nil {
-		targetProject = match[1]
-		instanceName = match[2]
-	} else {
-		instanceName = resourceName
-		if strings.Contains(resourceName, "/") {
-			parts := strings.Split(resourceName, "/")
-			instanceName = parts[len(parts)-1]
-		}
-	}
-
-	tryFetch := func(pid, inst string) int64 {
-		s, err := sqladmin.NewService(ctx, option.WithScopes(sqladmin.SqlserviceAdminScope))
-		if err != nil {
-			log.Printf("WARN: Failed to create sql service: %v", err)
-			return 0
-		}
-
-		resp, err := s.Instances.Get(pid, inst).Do()
-		if err != nil {
-			// Downgraded to DEBUG to avoid noise
-			log.Printf("DEBUG: Failed to get sql instance %s in %s: %v", inst, pid, err)
-			return 0
-		}
-
-		if resp.Settings != nil && resp.Settings.DataDiskSizeGb > 0 {
-			return resp.Settings.DataDiskSizeGb * 1024 * 1024 * 1024
-		}
-		return 0
-	}
-
-	// 1. Initial attempt
-	if size := tryFetch(targetProject, instanceName); size > 0 {
-		return size
-	}
-
-	// 2. Fallback
-	for _, wp := range a.WorkloadProjects {
-		if wp == targetProject {
-			continue
-		}
-		if size := tryFetch(wp, instanceName); size > 0 {
-			log.Printf("DEBUG: Found CloudSQL %s in workload project %s", instanceName, wp)
-			return size
-		}
-	}
-
-	return 0
-}
-
 func GetProjectID() string {
 	return os.Getenv("GOOGLE_CLOUD_PROJECT")
 }
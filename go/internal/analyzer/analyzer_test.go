@@ -38,7 +38,7 @@ func TestCalculateDailyBaselines(t *testing.T) {
 	}
 
 	// Run calculation
-	baselines := calculateDailyBaselines(jobs, anomalies, 3)
+	baselines := calculateDailyBaselines(jobs, anomalies, nil, 3)
 
 	// Verify results
 	// Expect 3 entries (Day 1, Day 2, Day 3)
@@ -0,0 +1,223 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Detector scores a single job against its resource's baseline and reports
+// why it looks anomalous, if at all. detectAnomalies runs every configured
+// Detector over each resource's time-ordered series, so several detectors
+// can flag the same job for different reasons.
+type Detector interface {
+	// Name identifies the detector, e.g. "mad_size". detectAnomalies prefixes
+	// each of the detector's Anomaly.Reasons with it ("mad_size: ..."), so a
+	// caller can filter Reasons down to one detector's output.
+	Name() string
+
+	// Detect evaluates job against the resource-wide stats s and the
+	// detector's own running state (seeded fresh per resource), returning
+	// zero or more reasons if job is anomalous.
+	Detect(job JobData, s ResourceStats, state *detectorState) []string
+}
+
+// detectorState threads the mutable, sequentially-updated per-resource,
+// per-detector state (seasonal EWMA buckets) through a resource's job
+// series as detectAnomalies walks it in time order. A fresh detectorState
+// is created per resource so buckets from one resource never leak into
+// another's baseline.
+type detectorState struct {
+	seasonal map[string]*seasonalBucketState
+}
+
+func newDetectorState() *detectorState {
+	return &detectorState{seasonal: make(map[string]*seasonalBucketState)}
+}
+
+type seasonalBucketState struct {
+	ewma  float64
+	mad   float64 // EWMA of the bucket's absolute deviation, used as a running MAD-like spread
+	ready bool
+}
+
+// seriesMetric lets the same detector implementation score either GiB
+// transferred or job duration, pulling the matching precomputed
+// median/MAD/avg/stddev out of ResourceStats instead of duplicating each
+// detector once per metric.
+type seriesMetric struct {
+	label   string
+	extract func(JobData) float64
+	avg     func(ResourceStats) float64
+	stddev  func(ResourceStats) float64
+	median  func(ResourceStats) float64
+	mad     func(ResourceStats) float64
+}
+
+var gibSeriesMetric = seriesMetric{
+	label:   "Size",
+	extract: func(j JobData) float64 { return j.GiBTransferred },
+	avg:     func(s ResourceStats) float64 { return s.AvgGiB },
+	stddev:  func(s ResourceStats) float64 { return s.StdDevGiB },
+	median:  func(s ResourceStats) float64 { return s.MedianGiB },
+	mad:     func(s ResourceStats) float64 { return s.MADGiB },
+}
+
+var durationSeriesMetric = seriesMetric{
+	label:   "Duration",
+	extract: func(j JobData) float64 { return j.DurationSeconds },
+	avg:     func(s ResourceStats) float64 { return s.AvgDurationSeconds },
+	stddev:  func(s ResourceStats) float64 { return s.StdDevDuration },
+	median:  func(s ResourceStats) float64 { return s.MedianDurationSeconds },
+	mad:     func(s ResourceStats) float64 { return s.MADDurationSeconds },
+}
+
+// defaultDetectors returns the Analyzer's default detector set (MAD plus
+// seasonal EWMA, for both size and duration), used whenever
+// Analyzer.Detectors is empty.
+func defaultDetectors() []Detector {
+	return []Detector{
+		MADDetector{Metric: gibSeriesMetric},
+		SeasonalEWMADetector{Metric: gibSeriesMetric},
+		MADDetector{Metric: durationSeriesMetric},
+		SeasonalEWMADetector{Metric: durationSeriesMetric},
+	}
+}
+
+// ZScoreDetector flags values more than Threshold standard deviations above
+// the resource's mean. This is the classic detector: easily poisoned by a
+// single huge backup dragging the mean/stddev up, so it is kept only for
+// callers that explicitly opt back into it via Analyzer.Detectors.
+type ZScoreDetector struct {
+	Metric    seriesMetric
+	Threshold float64 // defaults to zScoreThreshold
+}
+
+func (d ZScoreDetector) Name() string { return "zscore_" + strings.ToLower(d.Metric.label) }
+
+func (d ZScoreDetector) Detect(job JobData, s ResourceStats, _ *detectorState) []string {
+	threshold := d.Threshold
+	if threshold <= 0 {
+		threshold = zScoreThreshold
+	}
+
+	stddev := d.Metric.stddev(s)
+	if stddev == 0 {
+		return nil
+	}
+
+	z := (d.Metric.extract(job) - d.Metric.avg(s)) / stddev
+	if z > threshold {
+		return []string{fmt.Sprintf("%s Spike (Z=%.1f)", d.Metric.label, z)}
+	}
+	return nil
+}
+
+// MADDetector flags values whose modified Z-score, computed from the
+// resource's median and median absolute deviation, exceeds Threshold. It
+// falls back to doing nothing (rather than a noisy false positive) when
+// there isn't enough history or the window has zero spread.
+type MADDetector struct {
+	Metric    seriesMetric
+	Threshold float64 // defaults to madThreshold
+	MinJobs   int     // defaults to minJobsForRobustStats
+}
+
+func (d MADDetector) Name() string { return "mad_" + strings.ToLower(d.Metric.label) }
+
+func (d MADDetector) Detect(job JobData, s ResourceStats, _ *detectorState) []string {
+	threshold := d.Threshold
+	if threshold <= 0 {
+		threshold = madThreshold
+	}
+	minJobs := d.MinJobs
+	if minJobs <= 0 {
+		minJobs = minJobsForRobustStats
+	}
+
+	mad := d.Metric.mad(s)
+	if s.BackupJobCount < minJobs || mad == 0 {
+		return nil
+	}
+
+	modifiedZ := 0.6745 * (d.Metric.extract(job) - d.Metric.median(s)) / mad
+	if math.Abs(modifiedZ) > threshold {
+		return []string{fmt.Sprintf("Robust %s Spike (MAD Z=%.1f)", d.Metric.label, modifiedZ)}
+	}
+	return nil
+}
+
+// defaultSeasonalBucket buckets a job by day-of-week. Callers with
+// sub-daily schedules can supply their own BucketFn (e.g. t.Hour()) to a
+// SeasonalEWMADetector instead.
+func defaultSeasonalBucket(t time.Time) string {
+	return t.Weekday().String()
+}
+
+// SeasonalEWMADetector maintains one EWMA baseline per seasonal bucket
+// (day-of-week by default) per resource, so a resource that's naturally
+// bigger every Sunday doesn't get flagged every week. It flags a job when
+// it deviates from its bucket's EWMA by more than Threshold times the
+// bucket's own running MAD-like spread.
+type SeasonalEWMADetector struct {
+	Metric    seriesMetric
+	Alpha     float64                // defaults to ewmaAlpha
+	Threshold float64                // defaults to ewmaZThreshold, applied as a multiple of the bucket's spread
+	BucketFn  func(time.Time) string // defaults to defaultSeasonalBucket
+}
+
+func (d SeasonalEWMADetector) Name() string {
+	return "seasonal_ewma_" + strings.ToLower(d.Metric.label)
+}
+
+func (d SeasonalEWMADetector) Detect(job JobData, _ ResourceStats, state *detectorState) []string {
+	alpha := d.Alpha
+	if alpha <= 0 {
+		alpha = ewmaAlpha
+	}
+	k := d.Threshold
+	if k <= 0 {
+		k = ewmaZThreshold
+	}
+	bucketFn := d.BucketFn
+	if bucketFn == nil {
+		bucketFn = defaultSeasonalBucket
+	}
+
+	bucket := bucketFn(job.StartTime)
+	key := d.Metric.label + ":" + bucket
+	bs, ok := state.seasonal[key]
+	if !ok {
+		bs = &seasonalBucketState{}
+		state.seasonal[key] = bs
+	}
+
+	value := d.Metric.extract(job)
+
+	var reasons []string
+	if bs.ready {
+		// A bucket whose history so far is perfectly flat has mad == 0,
+		// which would otherwise zero out this guard forever -- floor the
+		// spread so a sudden jump off a flat baseline can still flag.
+		spread := bs.mad
+		if spread < minSeasonalSpread {
+			spread = minSeasonalSpread
+		}
+		deviation := value - bs.ewma
+		if math.Abs(deviation) > k*spread {
+			reasons = append(reasons, fmt.Sprintf("Seasonal %s Drift [%s] (dev=%.2f, baseline=%.2f)", d.Metric.label, bucket, deviation, bs.ewma))
+		}
+	}
+
+	if !bs.ready {
+		bs.ewma = value
+		bs.ready = true
+	} else {
+		absDev := math.Abs(value - bs.ewma)
+		bs.mad = alpha*absDev + (1-alpha)*bs.mad
+		bs.ewma = alpha*value + (1-alpha)*bs.ewma
+	}
+
+	return reasons
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMADDetector(t *testing.T) {
+	stats := ResourceStats{
+		BackupJobCount: minJobsForRobustStats,
+		MedianGiB:      10,
+		MADGiB:         1,
+	}
+
+	d := MADDetector{Metric: gibSeriesMetric}
+
+	if reasons := d.Detect(JobData{GiBTransferred: 10.5}, stats, nil); len(reasons) != 0 {
+		t.Errorf("Detect() on a near-median value = %v, want no reasons", reasons)
+	}
+
+	if reasons := d.Detect(JobData{GiBTransferred: 50}, stats, nil); len(reasons) == 0 {
+		t.Errorf("Detect() on a far-from-median value returned no reasons, want at least one")
+	}
+
+	// Below the minimum job count, the detector should stay silent even on
+	// an extreme value -- there isn't enough history to trust MAD yet.
+	sparse := stats
+	sparse.BackupJobCount = minJobsForRobustStats - 1
+	if reasons := d.Detect(JobData{GiBTransferred: 1000}, sparse, nil); len(reasons) != 0 {
+		t.Errorf("Detect() with BackupJobCount below MinJobs = %v, want no reasons", reasons)
+	}
+
+	// A zero MAD (e.g. every job transferred exactly the same size) would
+	// divide by zero if not guarded against.
+	zeroSpread := stats
+	zeroSpread.MADGiB = 0
+	if reasons := d.Detect(JobData{GiBTransferred: 1000}, zeroSpread, nil); len(reasons) != 0 {
+		t.Errorf("Detect() with MADGiB=0 = %v, want no reasons", reasons)
+	}
+}
+
+func TestSeasonalEWMADetectorWarmsUpBeforeFlagging(t *testing.T) {
+	d := SeasonalEWMADetector{
+		Metric:   gibSeriesMetric,
+		BucketFn: func(time.Time) string { return "fixed" }, // collapse every job into one bucket
+	}
+	state := newDetectorState()
+
+	// The first observation in a bucket just seeds the EWMA; it has no
+	// baseline to compare against yet, so it can never be flagged.
+	if reasons := d.Detect(JobData{GiBTransferred: 10, StartTime: time.Now()}, ResourceStats{}, state); len(reasons) != 0 {
+		t.Errorf("Detect() on the first job in a bucket = %v, want no reasons", reasons)
+	}
+
+	// Feed a run of stable values so the bucket's baseline/spread settle.
+	for i := 0; i < 10; i++ {
+		d.Detect(JobData{GiBTransferred: 10, StartTime: time.Now()}, ResourceStats{}, state)
+	}
+
+	if reasons := d.Detect(JobData{GiBTransferred: 500, StartTime: time.Now()}, ResourceStats{}, state); len(reasons) == 0 {
+		t.Errorf("Detect() on a large deviation from a settled baseline returned no reasons, want at least one")
+	}
+}
@@ -0,0 +1,331 @@
+package analyzer
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDispatchJobTTL is how long a completed/failed dispatch job's state
+// and result are kept in memory before eviction.
+const DefaultDispatchJobTTL = 30 * time.Minute
+
+// dispatchPriorityRank maps a PriorityClass to a numeric priority; higher
+// runs first. Unknown classes are treated as "scheduled".
+var dispatchPriorityRank = map[string]int{
+	"interactive": 100,
+	"adhoc":       50,
+	"scheduled":   0,
+}
+
+// JobID identifies a dispatched analysis job.
+type JobID string
+
+// DispatchRequest is a structured, on-demand analysis request, as opposed to
+// the filterName/sourceType pair Analyze takes directly. It's the payload a
+// Cloud Run / Pub/Sub front end would build from a user's "re-scan this
+// resource now" request.
+type DispatchRequest struct {
+	Filter        string
+	SourceType    string
+	TimeWindow    time.Duration
+	Resources     []string
+	PriorityClass string
+	MetaRequired  map[string]string
+	MetaOptional  map[string]string
+}
+
+// DispatchConfig describes the payload shapes Dispatch will accept, so
+// callers can discover the schema (e.g. to render a form) instead of
+// guessing at it.
+type DispatchConfig struct {
+	RequiredMetaKeys       []string
+	AllowedPriorityClasses []string
+}
+
+// DispatchStatus is the current state of a dispatched job.
+type DispatchStatus struct {
+	JobID     JobID     `json:"job_id"`
+	State     string    `json:"state"` // "queued", "running", "completed", "failed"
+	Error     string    `json:"error,omitempty"`
+	QueuedAt  time.Time `json:"queued_at"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+	EndedAt   time.Time `json:"ended_at,omitempty"`
+}
+
+type dispatchJob struct {
+	id       JobID
+	req      DispatchRequest
+	priority int
+	seq      int64 // tie-breaker so same-priority jobs stay FIFO
+
+	status DispatchStatus
+	result *AnalysisResult
+}
+
+// dispatchQueue is a container/heap priority queue ordered by priority desc,
+// then by arrival order.
+type dispatchQueue []*dispatchJob
+
+func (q dispatchQueue) Len() int { return len(q) }
+func (q dispatchQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q dispatchQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *dispatchQueue) Push(x interface{}) {
+	*q = append(*q, x.(*dispatchJob))
+}
+func (q *dispatchQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// dispatchManager holds the in-memory queue/state for Analyzer.Dispatch. It
+// lives behind a pointer field on Analyzer so Analyzer itself stays a plain
+// value type (no embedded mutex to accidentally copy).
+type dispatchManager struct {
+	mu    sync.Mutex
+	queue dispatchQueue
+	jobs  map[JobID]*dispatchJob
+	wake  chan struct{}
+	seq   int64
+	ttl   time.Duration
+
+	startOnce sync.Once
+}
+
+func newDispatchManager(ttl time.Duration) *dispatchManager {
+	if ttl <= 0 {
+		ttl = DefaultDispatchJobTTL
+	}
+	return &dispatchManager{
+		jobs: make(map[JobID]*dispatchJob),
+		wake: make(chan struct{}, 1),
+		ttl:  ttl,
+	}
+}
+
+func (a *Analyzer) ensureDispatcher() *dispatchManager {
+	if a.dispatcher == nil {
+		a.dispatcher = newDispatchManager(a.DispatchJobTTL)
+	}
+	a.dispatcher.startOnce.Do(func() {
+		go a.runDispatchLoop()
+	})
+	return a.dispatcher
+}
+
+// Dispatch validates req against DispatchConfig, enqueues it, and returns a
+// JobID the caller can poll via Status/Result. Higher PriorityClass jobs
+// (e.g. "interactive") preempt lower ones (e.g. "scheduled") already queued,
+// but never interrupt a job already running.
+func (a *Analyzer) Dispatch(ctx context.Context, req DispatchRequest) (JobID, error) {
+	for _, key := range a.DispatchConfig.RequiredMetaKeys {
+		if _, ok := req.MetaRequired[key]; !ok {
+			return "", fmt.Errorf("dispatch request missing required meta key %q", key)
+		}
+	}
+
+	if len(a.DispatchConfig.AllowedPriorityClasses) > 0 && req.PriorityClass != "" {
+		allowed := false
+		for _, pc := range a.DispatchConfig.AllowedPriorityClasses {
+			if pc == req.PriorityClass {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "", fmt.Errorf("dispatch request priority class %q is not in DispatchConfig.AllowedPriorityClasses", req.PriorityClass)
+		}
+	}
+
+	dm := a.ensureDispatcher()
+	dm.evictExpired()
+
+	seq := atomic.AddInt64(&dm.seq, 1)
+	id := JobID(fmt.Sprintf("job-%d", seq))
+
+	job := &dispatchJob{
+		id:       id,
+		req:      req,
+		priority: dispatchPriorityRank[req.PriorityClass],
+		seq:      seq,
+		status: DispatchStatus{
+			JobID:    id,
+			State:    "queued",
+			QueuedAt: time.Now(),
+		},
+	}
+
+	dm.mu.Lock()
+	dm.jobs[id] = job
+	heap.Push(&dm.queue, job)
+	dm.mu.Unlock()
+
+	select {
+	case dm.wake <- struct{}{}:
+	default:
+	}
+
+	return id, nil
+}
+
+// Status returns the current state of a dispatched job.
+func (a *Analyzer) Status(jobID JobID) (DispatchStatus, error) {
+	if a.dispatcher == nil {
+		return DispatchStatus{}, fmt.Errorf("unknown job %q", jobID)
+	}
+	a.dispatcher.mu.Lock()
+	defer a.dispatcher.mu.Unlock()
+
+	job, ok := a.dispatcher.jobs[jobID]
+	if !ok {
+		return DispatchStatus{}, fmt.Errorf("unknown job %q", jobID)
+	}
+	return job.status, nil
+}
+
+// Result returns the AnalysisResult for a completed job. It returns an error
+// if the job doesn't exist, hasn't finished, or failed.
+func (a *Analyzer) Result(jobID JobID) (*AnalysisResult, error) {
+	if a.dispatcher == nil {
+		return nil, fmt.Errorf("unknown job %q", jobID)
+	}
+	a.dispatcher.mu.Lock()
+	defer a.dispatcher.mu.Unlock()
+
+	job, ok := a.dispatcher.jobs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("unknown job %q", jobID)
+	}
+	switch job.status.State {
+	case "completed":
+		return job.result, nil
+	case "failed":
+		return nil, fmt.Errorf("job %q failed: %s", jobID, job.status.Error)
+	default:
+		return nil, fmt.Errorf("job %q is not finished yet (state=%s)", jobID, job.status.State)
+	}
+}
+
+// runDispatchLoop is the single dispatcher worker: it blocks on wake,
+// pops the highest-priority queued job, and runs it to completion before
+// picking up the next one. One worker is enough here since the heavy
+// lifting (log fetch/enrichment) already fans out internally via Analyze.
+func (a *Analyzer) runDispatchLoop() {
+	dm := a.dispatcher
+	for range dm.wake {
+		for {
+			dm.mu.Lock()
+			if dm.queue.Len() == 0 {
+				dm.mu.Unlock()
+				break
+			}
+			job := heap.Pop(&dm.queue).(*dispatchJob)
+			job.status.State = "running"
+			job.status.StartedAt = time.Now()
+			dm.mu.Unlock()
+
+			a.runDispatchJob(context.Background(), job)
+		}
+	}
+}
+
+func (a *Analyzer) runDispatchJob(ctx context.Context, job *dispatchJob) {
+	dm := a.dispatcher
+
+	// Run against a scoped copy so a one-off Days/filter override for this
+	// job doesn't race the shared Analyzer's own fields.
+	scoped := *a
+	scoped.dispatcher = nil
+	if job.req.TimeWindow > 0 {
+		days := int(job.req.TimeWindow.Hours() / 24)
+		if days < 1 {
+			days = 1
+		}
+		scoped.Days = days
+	}
+
+	sourceType := job.req.SourceType
+	if sourceType == "" {
+		sourceType = "all"
+	}
+
+	result, err := scoped.Analyze(ctx, job.req.Filter, sourceType)
+	if err == nil && len(job.req.Resources) > 0 {
+		result = filterResultToResources(result, job.req.Resources)
+	}
+
+	dm.mu.Lock()
+	job.status.EndedAt = time.Now()
+	if err != nil {
+		job.status.State = "failed"
+		job.status.Error = err.Error()
+	} else {
+		job.status.State = "completed"
+		job.result = result
+	}
+	dm.mu.Unlock()
+}
+
+// filterResultToResources narrows an AnalysisResult down to the named
+// resources, for callers that dispatched a targeted re-scan rather than a
+// full baseline run.
+func filterResultToResources(result *AnalysisResult, resources []string) *AnalysisResult {
+	if result == nil || len(resources) == 0 {
+		return result
+	}
+
+	wanted := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		wanted[r] = true
+	}
+
+	keep := func(stats []ResourceStats) []ResourceStats {
+		var filtered []ResourceStats
+		for _, s := range stats {
+			if wanted[s.ResourceName] {
+				filtered = append(filtered, s)
+			}
+		}
+		return filtered
+	}
+
+	result.VaultWorkloads.ResourceStats = keep(result.VaultWorkloads.ResourceStats)
+	result.ApplianceWorkloads.ResourceStats = keep(result.ApplianceWorkloads.ResourceStats)
+
+	var anomalies []Anomaly
+	for _, an := range result.Anomalies {
+		if wanted[an.Resource] {
+			anomalies = append(anomalies, an)
+		}
+	}
+	result.Anomalies = anomalies
+
+	return result
+}
+
+// evictExpired drops completed/failed jobs older than the manager's TTL.
+func (dm *dispatchManager) evictExpired() {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	now := time.Now()
+	for id, job := range dm.jobs {
+		if job.status.EndedAt.IsZero() {
+			continue
+		}
+		if now.Sub(job.status.EndedAt) > dm.ttl {
+			delete(dm.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// FormatDuration renders seconds as a human-friendly "Hh Mm Ss" string
+// (e.g. "1h 05m 30s"), dropping leading zero units so a 45s job reads
+// "45s" rather than "0h 00m 45s". Notifier/formatter output uses this
+// instead of a raw DurationSeconds float so on-call engineers don't have
+// to do the arithmetic themselves.
+func FormatDuration(seconds float64) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	total := int64(seconds + 0.5) // round to the nearest second
+
+	h := total / 3600
+	m := (total % 3600) / 60
+	s := total % 60
+
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh %02dm %02ds", h, m, s)
+	case m > 0:
+		return fmt.Sprintf("%dm %02ds", m, s)
+	default:
+		return fmt.Sprintf("%ds", s)
+	}
+}
+
+// LocalLocation returns the time.Location wall-clock times should be
+// rendered in, honoring the TZ environment variable (e.g.
+// "America/New_York") so on-call engineers see job start/finish times in
+// the deployment's own zone instead of doing UTC math. Falls back to UTC
+// when TZ is unset or names an unknown zone.
+func LocalLocation() *time.Location {
+	tz := os.Getenv("TZ")
+	if tz == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
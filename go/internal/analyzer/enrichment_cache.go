@@ -0,0 +1,317 @@
+package analyzer
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// enrichmentCacheVersion is bumped whenever the on-disk schema changes so
+// stale caches from an older binary are discarded instead of misread.
+const enrichmentCacheVersion = 1
+
+// DefaultEnrichmentCacheTTL is how long a cached resource size is trusted
+// before it is considered stale and re-fetched from the GCP APIs. Used for
+// instance disk totals; fast-churning resource types get a shorter default
+// via enrichmentTTLForType.
+const DefaultEnrichmentCacheTTL = 24 * time.Hour
+
+// DiskCloudSQLEnrichmentTTL is the default TTL for Persistent Disk and
+// Cloud SQL size lookups, which are cheaper to re-fetch and more likely to
+// change size than a GCE instance's disk total.
+const DiskCloudSQLEnrichmentTTL = 6 * time.Hour
+
+// NegativeEnrichmentTTL is how long a "resource not found" result is cached
+// to suppress repeated 404s against a renamed or deleted resource, before
+// the next run tries the API again.
+const NegativeEnrichmentTTL = 15 * time.Minute
+
+// DefaultEnrichmentCacheMaxEntries bounds the in-memory LRU when
+// EnrichmentCache.MaxEntries is left at zero.
+const DefaultEnrichmentCacheMaxEntries = 10000
+
+// enrichmentTTLForType returns the default TTL for a cached lookup of the
+// given (lowercased) resourceType, used whenever the caller doesn't pass an
+// explicit override.
+func enrichmentTTLForType(resourceType string) time.Duration {
+	if strings.Contains(resourceType, "disk") || strings.Contains(resourceType, "cloud sql") {
+		return DiskCloudSQLEnrichmentTTL
+	}
+	return DefaultEnrichmentCacheTTL
+}
+
+// EnrichmentEntry is a single cached resource-size lookup. Negative entries
+// (Negative == true) record a confirmed "no size available" result rather
+// than an unpopulated zero, so calculateStatistics can tell "we checked and
+// there's nothing" apart from "we haven't checked yet".
+type EnrichmentEntry struct {
+	ResourceName string        `json:"resource_name"`
+	ProjectID    string        `json:"project_id"`
+	ResourceType string        `json:"resource_type"`
+	SizeBytes    int64         `json:"size_bytes"`
+	Negative     bool          `json:"negative,omitempty"`
+	FetchedAt    time.Time     `json:"fetched_at"`
+	TTL          time.Duration `json:"ttl"`
+}
+
+func (e EnrichmentEntry) stale(now time.Time) bool {
+	ttl := e.TTL
+	if ttl <= 0 {
+		ttl = DefaultEnrichmentCacheTTL
+	}
+	return now.Sub(e.FetchedAt) > ttl
+}
+
+// EnrichmentCache is a disk-backed cache of resource size lookups, keyed by
+// "projectID|resourceType|resourceName" so the same resource name seen in
+// two different projects doesn't collide. An in-memory LRU (bounded by
+// MaxEntries) caps how many entries are kept around across runs on estates
+// with a lot of resource churn. It is safe for concurrent use.
+type EnrichmentCache struct {
+	Entries map[string]EnrichmentEntry `json:"entries"`
+	Version int                        `json:"version"`
+
+	// MaxEntries bounds the cache's size; the least-recently-used entry is
+	// evicted once a Set/SetNegative would exceed it. Defaults to
+	// DefaultEnrichmentCacheMaxEntries when zero.
+	MaxEntries int `json:"-"`
+
+	path string
+	mu   sync.Mutex
+
+	lru      *list.List
+	lruElems map[string]*list.Element
+
+	hits    int
+	misses  int
+	stale   int
+	negHits int
+}
+
+// enrichmentCacheKey builds the cache key for a resource.
+func enrichmentCacheKey(projectID, resourceType, resourceName string) string {
+	return fmt.Sprintf("%s|%s|%s", projectID, resourceType, resourceName)
+}
+
+// NewEnrichmentCache creates an empty, unbacked cache. Use
+// LoadEnrichmentCache to read one from disk.
+func NewEnrichmentCache() *EnrichmentCache {
+	return &EnrichmentCache{
+		Entries:  make(map[string]EnrichmentEntry),
+		Version:  enrichmentCacheVersion,
+		lru:      list.New(),
+		lruElems: make(map[string]*list.Element),
+	}
+}
+
+// LoadEnrichmentCache reads a cache from path. A missing file, or one with a
+// mismatched Version, yields a fresh empty cache rather than an error so
+// first-run and schema upgrades behave the same way.
+func LoadEnrichmentCache(path string) (*EnrichmentCache, error) {
+	c := NewEnrichmentCache()
+	c.path = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("failed to read enrichment cache %s: %w", path, err)
+	}
+
+	var onDisk EnrichmentCache
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		return nil, fmt.Errorf("failed to parse enrichment cache %s: %w", path, err)
+	}
+
+	if onDisk.Version != enrichmentCacheVersion || onDisk.Entries == nil {
+		return c, nil
+	}
+
+	c.Entries = onDisk.Entries
+	for key := range c.Entries {
+		c.lruElems[key] = c.lru.PushBack(key)
+	}
+	c.evictIfNeededLocked()
+	return c, nil
+}
+
+// ensureLRU lazily initializes the LRU bookkeeping, so an EnrichmentCache
+// value that reached Get/Set without going through NewEnrichmentCache (e.g.
+// after json.Unmarshal into a fresh zero value) doesn't panic on a nil list.
+func (c *EnrichmentCache) ensureLRU() {
+	if c.lru == nil {
+		c.lru = list.New()
+	}
+	if c.lruElems == nil {
+		c.lruElems = make(map[string]*list.Element)
+	}
+}
+
+func (c *EnrichmentCache) touchLocked(key string) {
+	if elem, ok := c.lruElems[key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.lruElems[key] = c.lru.PushFront(key)
+}
+
+func (c *EnrichmentCache) removeLocked(key string) {
+	if elem, ok := c.lruElems[key]; ok {
+		c.lru.Remove(elem)
+		delete(c.lruElems, key)
+	}
+	delete(c.Entries, key)
+}
+
+func (c *EnrichmentCache) evictIfNeededLocked() {
+	maxEntries := c.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultEnrichmentCacheMaxEntries
+	}
+	for len(c.Entries) > maxEntries {
+		back := c.lru.Back()
+		if back == nil {
+			return
+		}
+		c.removeLocked(back.Value.(string))
+	}
+}
+
+// Get returns the cached size for a resource if present and not stale.
+// found reports whether a usable (non-stale) cache entry exists at all;
+// negative reports whether that entry is a confirmed "not found" result
+// rather than a real size, in which case sizeBytes is always 0.
+func (c *EnrichmentCache) Get(projectID, resourceType, resourceName string) (sizeBytes int64, found bool, negative bool) {
+	if c == nil {
+		return 0, false, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ensureLRU()
+
+	key := enrichmentCacheKey(projectID, resourceType, resourceName)
+	entry, ok := c.Entries[key]
+	if !ok {
+		c.misses++
+		return 0, false, false
+	}
+	if entry.stale(time.Now()) {
+		c.stale++
+		c.removeLocked(key)
+		return 0, false, false
+	}
+
+	c.touchLocked(key)
+	if entry.Negative {
+		c.negHits++
+		return 0, true, true
+	}
+	c.hits++
+	return entry.SizeBytes, true, false
+}
+
+// Set stores a freshly fetched size, replacing any existing entry. A zero
+// ttl falls back to enrichmentTTLForType(resourceType).
+func (c *EnrichmentCache) Set(projectID, resourceType, resourceName string, sizeBytes int64, ttl time.Duration) {
+	c.setEntry(projectID, resourceType, resourceName, sizeBytes, ttl, false)
+}
+
+// SetNegative records a confirmed "resource not found" result, so repeated
+// 404s against a renamed/deleted resource don't keep re-hitting the GCP
+// APIs every run. A zero ttl falls back to NegativeEnrichmentTTL.
+func (c *EnrichmentCache) SetNegative(projectID, resourceType, resourceName string, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = NegativeEnrichmentTTL
+	}
+	c.setEntry(projectID, resourceType, resourceName, 0, ttl, true)
+}
+
+func (c *EnrichmentCache) setEntry(projectID, resourceType, resourceName string, sizeBytes int64, ttl time.Duration, negative bool) {
+	if c == nil {
+		return
+	}
+	if ttl <= 0 && !negative {
+		ttl = enrichmentTTLForType(resourceType)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ensureLRU()
+
+	key := enrichmentCacheKey(projectID, resourceType, resourceName)
+	c.Entries[key] = EnrichmentEntry{
+		ResourceName: resourceName,
+		ProjectID:    projectID,
+		ResourceType: resourceType,
+		SizeBytes:    sizeBytes,
+		Negative:     negative,
+		FetchedAt:    time.Now(),
+		TTL:          ttl,
+	}
+	c.touchLocked(key)
+	c.evictIfNeededLocked()
+}
+
+// Invalidate drops every cached entry (across projects/resource types) for
+// the named resource, forcing the next calculateStatistics run to re-fetch
+// it instead of trusting whatever is cached. Used by
+// Analyzer.InvalidateEnrichment and the --refresh-enrichment CLI flag.
+func (c *EnrichmentCache) Invalidate(resourceName string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ensureLRU()
+
+	for key, entry := range c.Entries {
+		if entry.ResourceName == resourceName {
+			c.removeLocked(key)
+		}
+	}
+}
+
+// Stats returns cumulative hit/miss/stale/negative-hit counters since the
+// cache was created or loaded, for surfacing in DebugLog.
+func (c *EnrichmentCache) Stats() (hits, misses, stale, negHits int) {
+	if c == nil {
+		return 0, 0, 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.stale, c.negHits
+}
+
+// Save flushes the cache to its backing file, creating parent directories as
+// needed. A no-op if the cache was never loaded from/pointed at a path.
+func (c *EnrichmentCache) Save() error {
+	if c == nil || c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create enrichment cache dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal enrichment cache: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write enrichment cache: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
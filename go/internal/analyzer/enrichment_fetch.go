@@ -0,0 +1,475 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	compute_v1 "cloud.google.com/go/compute/apiv1"
+	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	filestore_v1 "cloud.google.com/go/filestore/apiv1"
+	filestorepb "cloud.google.com/go/filestore/apiv1/filestorepb"
+	monitoring_v3 "cloud.google.com/go/monitoring/apiv3/v2"
+	monitoringpb "cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	sqladmin "google.golang.org/api/sqladmin/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultEnrichmentConcurrency is how many resource size lookups
+// enrichConcurrent runs at once when Analyzer.EnrichmentConcurrency is unset.
+const DefaultEnrichmentConcurrency = 8
+
+// sharedEnrichmentClients holds the REST clients enrichConcurrent's workers
+// share, instead of each resource lookup constructing (and immediately
+// discarding) its own client the way the old per-call tryFetch closures did.
+// defaultFetcherRegistry wraps each of these in a ResourceFetcher.
+type sharedEnrichmentClients struct {
+	instances   *compute_v1.InstancesClient
+	disks       *compute_v1.DisksClient
+	regionDisks *compute_v1.RegionDisksClient
+	sql         *sqladmin.Service
+	filestore   *filestore_v1.CloudFilestoreManagerClient
+	monitoring  *monitoring_v3.MetricClient
+	bigquery    *bigquery.Service
+}
+
+func newSharedEnrichmentClients(ctx context.Context) (*sharedEnrichmentClients, error) {
+	instances, err := compute_v1.NewInstancesRESTClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instances client: %w", err)
+	}
+	disks, err := compute_v1.NewDisksRESTClient(ctx)
+	if err != nil {
+		instances.Close()
+		return nil, fmt.Errorf("failed to create disks client: %w", err)
+	}
+	regionDisks, err := compute_v1.NewRegionDisksRESTClient(ctx)
+	if err != nil {
+		instances.Close()
+		disks.Close()
+		return nil, fmt.Errorf("failed to create region disks client: %w", err)
+	}
+	sql, err := sqladmin.NewService(ctx, option.WithScopes(sqladmin.SqlserviceAdminScope))
+	if err != nil {
+		instances.Close()
+		disks.Close()
+		regionDisks.Close()
+		return nil, fmt.Errorf("failed to create sql service: %w", err)
+	}
+	filestore, err := filestore_v1.NewCloudFilestoreManagerClient(ctx)
+	if err != nil {
+		instances.Close()
+		disks.Close()
+		regionDisks.Close()
+		return nil, fmt.Errorf("failed to create filestore client: %w", err)
+	}
+	monitoring, err := monitoring_v3.NewMetricClient(ctx)
+	if err != nil {
+		instances.Close()
+		disks.Close()
+		regionDisks.Close()
+		filestore.Close()
+		return nil, fmt.Errorf("failed to create monitoring client: %w", err)
+	}
+	bq, err := bigquery.NewService(ctx)
+	if err != nil {
+		instances.Close()
+		disks.Close()
+		regionDisks.Close()
+		filestore.Close()
+		monitoring.Close()
+		return nil, fmt.Errorf("failed to create bigquery service: %w", err)
+	}
+	return &sharedEnrichmentClients{
+		instances:   instances,
+		disks:       disks,
+		regionDisks: regionDisks,
+		sql:         sql,
+		filestore:   filestore,
+		monitoring:  monitoring,
+		bigquery:    bq,
+	}, nil
+}
+
+func (c *sharedEnrichmentClients) Close() {
+	c.instances.Close()
+	c.disks.Close()
+	c.regionDisks.Close()
+	c.filestore.Close()
+	c.monitoring.Close()
+}
+
+// enrichmentRequest is one resource awaiting a size lookup via enrichConcurrent.
+type enrichmentRequest struct {
+	name         string
+	resourceType string
+	projectID    string
+}
+
+// enrichConcurrent resolves every request's size via fn across a bounded
+// worker pool (Analyzer.EnrichmentConcurrency, default
+// DefaultEnrichmentConcurrency) — the same ForEachJob fan-out Analyze already
+// uses for per-project log fetches. It returns a name->sizeBytes map for
+// whichever lookups resolved to a non-zero size, plus the first error hit by
+// any worker; ctx is cancelled for the rest of the pool as soon as one fn
+// call errors, same as ForEachJob.
+func (a *Analyzer) enrichConcurrent(ctx context.Context, requests []enrichmentRequest, fn func(ctx context.Context, req enrichmentRequest) (int64, error)) (map[string]int64, error) {
+	concurrency := a.EnrichmentConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultEnrichmentConcurrency
+	}
+
+	results := make([]int64, len(requests))
+	err := ForEachJob(ctx, len(requests), concurrency, func(ctx context.Context, idx int) error {
+		size, err := fn(ctx, requests[idx])
+		if err != nil {
+			return err
+		}
+		results[idx] = size
+		return nil
+	})
+
+	out := make(map[string]int64, len(requests))
+	for i, req := range requests {
+		if results[i] > 0 {
+			out[req.name] = results[i]
+		}
+	}
+	return out, err
+}
+
+var (
+	gceInstanceResourceRe = regexp.MustCompile(`projects/([^/]+)/zones/([^/]+)/instances/([^/]+)`)
+	gceDiskResourceRe     = regexp.MustCompile(`projects/([^/]+)/zones/([^/]+)/disks/([^/]+)`)
+	regionDiskResourceRe  = regexp.MustCompile(`projects/([^/]+)/regions/([^/]+)/disks/([^/]+)`)
+	cloudSQLResourceRe    = regexp.MustCompile(`projects/([^/]+)/instances/([^/]+)`)
+	filestoreResourceRe   = regexp.MustCompile(`projects/([^/]+)/locations/([^/]+)/instances/([^/]+)`)
+)
+
+// fetchGCEInstanceSize resolves a GCE instance's total attached-disk size,
+// parsing project/zone from resourceName and falling back to each workload
+// project, and reuses c.instances instead of constructing a fresh client per
+// resource.
+func (c *sharedEnrichmentClients) fetchGCEInstanceSize(ctx context.Context, workloadProjects []string, projectID, resourceName string) int64 {
+	targetProject := projectID
+	var targetZone, instanceName string
+
+	if match := gceInstanceResourceRe.FindStringSubmatch(resourceName); match != nil {
+		targetProject = match[1]
+		targetZone = match[2]
+		instanceName = match[3]
+	} else {
+		instanceName = resourceName
+		if strings.Contains(resourceName, "/") {
+			parts := strings.Split(resourceName, "/")
+			instanceName = parts[len(parts)-1]
+		}
+	}
+
+	tryFetch := func(pid, zone, inst string) int64 {
+		if zone != "" {
+			req := &computepb.GetInstanceRequest{Project: pid, Zone: zone, Instance: inst}
+			resp, err := c.instances.Get(ctx, req)
+			if err == nil {
+				return calculateDiskSize(resp)
+			}
+		}
+
+		req := &computepb.AggregatedListInstancesRequest{
+			Project: pid,
+			Filter:  proto.String(fmt.Sprintf("name = %s", inst)),
+		}
+		it := c.instances.AggregatedList(ctx, req)
+		for {
+			pair, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return 0
+			}
+			if pair.Value.Instances != nil {
+				for _, instance := range pair.Value.Instances {
+					if instance.GetName() == inst {
+						return calculateDiskSize(instance)
+					}
+				}
+			}
+		}
+		return 0
+	}
+
+	if size := tryFetch(targetProject, targetZone, instanceName); size > 0 {
+		return size
+	}
+	for _, wp := range workloadProjects {
+		if wp == targetProject {
+			continue
+		}
+		if size := tryFetch(wp, targetZone, instanceName); size > 0 {
+			log.Printf("DEBUG: Found %s in workload project %s", instanceName, wp)
+			return size
+		}
+	}
+	return 0
+}
+
+// fetchGCEDiskSize resolves a zonal Persistent Disk's size, falling back to
+// each workload project, reusing c.disks.
+func (c *sharedEnrichmentClients) fetchGCEDiskSize(ctx context.Context, workloadProjects []string, projectID, resourceName string) int64 {
+	match := gceDiskResourceRe.FindStringSubmatch(resourceName)
+	if match == nil {
+		return 0
+	}
+	targetProject := match[1]
+	targetZone := match[2]
+	diskName := match[3]
+
+	tryFetch := func(pid, zone, dName string) int64 {
+		req := &computepb.GetDiskRequest{Project: pid, Zone: zone, Disk: dName}
+		resp, err := c.disks.Get(ctx, req)
+		if err == nil {
+			return resp.GetSizeGb() * 1024 * 1024 * 1024
+		}
+		return 0
+	}
+
+	if size := tryFetch(targetProject, targetZone, diskName); size > 0 {
+		return size
+	}
+	for _, wp := range workloadProjects {
+		if wp == targetProject {
+			continue
+		}
+		if size := tryFetch(wp, targetZone, diskName); size > 0 {
+			log.Printf("DEBUG: Found disk %s in workload project %s", diskName, wp)
+			return size
+		}
+	}
+	return 0
+}
+
+// fetchRegionDiskSize handles regional Persistent Disks
+// (projects/*/regions/*/disks/*), which live behind a separate
+// RegionDisksClient from zonal disks.
+func (c *sharedEnrichmentClients) fetchRegionDiskSize(ctx context.Context, workloadProjects []string, projectID, resourceName string) int64 {
+	match := regionDiskResourceRe.FindStringSubmatch(resourceName)
+	if match == nil {
+		return 0
+	}
+	targetProject := match[1]
+	region := match[2]
+	diskName := match[3]
+
+	tryFetch := func(pid, rgn, dName string) int64 {
+		req := &computepb.GetRegionDiskRequest{Project: pid, Region: rgn, Disk: dName}
+		resp, err := c.regionDisks.Get(ctx, req)
+		if err == nil {
+			return resp.GetSizeGb() * 1024 * 1024 * 1024
+		}
+		return 0
+	}
+
+	if size := tryFetch(targetProject, region, diskName); size > 0 {
+		return size
+	}
+	for _, wp := range workloadProjects {
+		if wp == targetProject {
+			continue
+		}
+		if size := tryFetch(wp, region, diskName); size > 0 {
+			log.Printf("DEBUG: Found regional disk %s in workload project %s", diskName, wp)
+			return size
+		}
+	}
+	return 0
+}
+
+// fetchCloudSQLSize resolves a Cloud SQL instance's data disk size, falling
+// back to each workload project, reusing c.sql.
+func (c *sharedEnrichmentClients) fetchCloudSQLSize(ctx context.Context, workloadProjects []string, projectID, resourceName string) int64 {
+	targetProject := projectID
+	var instanceName string
+
+	if match := cloudSQLResourceRe.FindStringSubmatch(resourceName); match != nil {
+		targetProject = match[1]
+		instanceName = match[2]
+	} else {
+		instanceName = resourceName
+		if strings.Contains(resourceName, "/") {
+			parts := strings.Split(resourceName, "/")
+			instanceName = parts[len(parts)-1]
+		}
+	}
+
+	tryFetch := func(pid, inst string) int64 {
+		resp, err := c.sql.Instances.Get(pid, inst).Context(ctx).Do()
+		if err != nil {
+			log.Printf("DEBUG: Failed to get sql instance %s in %s: %v", inst, pid, err)
+			return 0
+		}
+		if resp.Settings != nil && resp.Settings.DataDiskSizeGb > 0 {
+			return resp.Settings.DataDiskSizeGb * 1024 * 1024 * 1024
+		}
+		return 0
+	}
+
+	if size := tryFetch(targetProject, instanceName); size > 0 {
+		return size
+	}
+	for _, wp := range workloadProjects {
+		if wp == targetProject {
+			continue
+		}
+		if size := tryFetch(wp, instanceName); size > 0 {
+			log.Printf("DEBUG: Found CloudSQL %s in workload project %s", instanceName, wp)
+			return size
+		}
+	}
+	return 0
+}
+
+// fetchFilestoreSize sums a Filestore instance's file shares' capacity.
+func (c *sharedEnrichmentClients) fetchFilestoreSize(ctx context.Context, workloadProjects []string, projectID, resourceName string) int64 {
+	match := filestoreResourceRe.FindStringSubmatch(resourceName)
+	if match == nil {
+		return 0
+	}
+	targetProject := match[1]
+	location := match[2]
+	instanceName := match[3]
+
+	tryFetch := func(pid, loc, inst string) int64 {
+		name := fmt.Sprintf("projects/%s/locations/%s/instances/%s", pid, loc, inst)
+		resp, err := c.filestore.GetInstance(ctx, &filestorepb.GetInstanceRequest{Name: name})
+		if err != nil {
+			log.Printf("DEBUG: Failed to get filestore instance %s: %v", name, err)
+			return 0
+		}
+		var totalGB int64
+		for _, share := range resp.GetFileShares() {
+			totalGB += share.GetCapacityGb()
+		}
+		return totalGB * 1024 * 1024 * 1024
+	}
+
+	if size := tryFetch(targetProject, location, instanceName); size > 0 {
+		return size
+	}
+	for _, wp := range workloadProjects {
+		if wp == targetProject {
+			continue
+		}
+		if size := tryFetch(wp, location, instanceName); size > 0 {
+			log.Printf("DEBUG: Found filestore instance %s in workload project %s", instanceName, wp)
+			return size
+		}
+	}
+	return 0
+}
+
+// fetchGCSBucketSize resolves a bucket's current size from the Cloud
+// Monitoring storage.googleapis.com/storage/total_bytes metric (buckets
+// don't expose size via the Storage API directly), using the latest sample
+// from the last two days.
+func (c *sharedEnrichmentClients) fetchGCSBucketSize(ctx context.Context, projectID, resourceName string) int64 {
+	bucketName := resourceName
+	if strings.Contains(resourceName, "/") {
+		parts := strings.Split(resourceName, "/")
+		bucketName = parts[len(parts)-1]
+	}
+
+	now := time.Now()
+	req := &monitoringpb.ListTimeSeriesRequest{
+		Name:   fmt.Sprintf("projects/%s", projectID),
+		Filter: fmt.Sprintf(`metric.type = "storage.googleapis.com/storage/total_bytes" AND resource.label.bucket_name = "%s"`, bucketName),
+		Interval: &monitoringpb.TimeInterval{
+			StartTime: timestamppb.New(now.Add(-48 * time.Hour)),
+			EndTime:   timestamppb.New(now),
+		},
+		View: monitoringpb.ListTimeSeriesRequest_FULL,
+	}
+
+	it := c.monitoring.ListTimeSeries(ctx, req)
+	var latest int64
+	var latestAt time.Time
+	for {
+		series, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Printf("DEBUG: Failed to list time series for bucket %s: %v", bucketName, err)
+			break
+		}
+		for _, point := range series.GetPoints() {
+			if at := point.GetInterval().GetEndTime().AsTime(); at.After(latestAt) {
+				latestAt = at
+				latest = point.GetValue().GetInt64Value()
+			}
+		}
+	}
+	return latest
+}
+
+// fetchBigQueryDatasetSize sums the NumBytes of every table in a dataset.
+// resourceName may be a bare dataset ID or "project:dataset"/".../dataset".
+func (c *sharedEnrichmentClients) fetchBigQueryDatasetSize(ctx context.Context, projectID, resourceName string) int64 {
+	datasetID := resourceName
+	if strings.Contains(datasetID, "/") {
+		parts := strings.Split(datasetID, "/")
+		datasetID = parts[len(parts)-1]
+	} else if strings.Contains(datasetID, ":") {
+		parts := strings.SplitN(datasetID, ":", 2)
+		datasetID = parts[len(parts)-1]
+	}
+
+	var totalBytes int64
+	pageToken := ""
+	for {
+		call := c.bigquery.Tables.List(projectID, datasetID).Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			log.Printf("DEBUG: Failed to list tables for dataset %s in %s: %v", datasetID, projectID, err)
+			return 0
+		}
+		for _, t := range resp.Tables {
+			if t.TableReference == nil {
+				continue
+			}
+			table, err := c.bigquery.Tables.Get(projectID, datasetID, t.TableReference.TableId).Context(ctx).Do()
+			if err != nil {
+				log.Printf("DEBUG: Failed to get table %s.%s.%s: %v", projectID, datasetID, t.TableReference.TableId, err)
+				continue
+			}
+			totalBytes += table.NumBytes
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	return totalBytes
+}
+
+// fetchSize resolves req's size via registry (a.FetcherRegistry, or the
+// default registry enrichResourceSizes builds when that is nil), replacing the old if/else chain
+// calculateStatistics used to run inline per resource. See fetcher.go for
+// the ResourceFetcher interface and registry.
+func (a *Analyzer) fetchSize(ctx context.Context, registry *FetcherRegistry, req enrichmentRequest) (int64, error) {
+	fetcher := registry.resolve(req.resourceType, req.name)
+	if fetcher == nil {
+		return 0, nil
+	}
+	return fetcher.FetchSizeBytes(ctx, req.projectID, req.name)
+}
@@ -0,0 +1,190 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+)
+
+// ResourceFetcher resolves a protected resource's current size in bytes from
+// its owning GCP API. Third parties (and tests) register their own
+// ResourceFetcher on a FetcherRegistry instead of editing a dispatch switch
+// in the analyzer core, so adding support for a new resource type (GKE PVCs,
+// Spanner, etc.) doesn't require touching calculateStatistics.
+type ResourceFetcher interface {
+	// Name identifies the fetcher for logging, e.g. "gce_disk".
+	Name() string
+
+	// Matches reports whether this fetcher handles a job's resourceType and
+	// resourceName. The first matching fetcher in the registry wins.
+	Matches(resourceType, resourceName string) bool
+
+	// FetchSizeBytes resolves the resource's current size in bytes. A size
+	// of 0 (with a nil error) means "nothing found", which enrichResourceSizes
+	// negative-caches rather than treating as fatal.
+	FetchSizeBytes(ctx context.Context, projectID, resourceName string) (int64, error)
+}
+
+// FetcherRegistry holds the ordered list of ResourceFetchers the enrichment
+// pass consults for each pending resource. The first fetcher whose Matches
+// returns true handles the lookup, so register more specific fetchers (e.g.
+// "vmware", which has no size) ahead of broader ones.
+type FetcherRegistry struct {
+	fetchers []ResourceFetcher
+}
+
+// NewFetcherRegistry returns an empty registry. Callers populate it with
+// Register, or use defaultFetcherRegistry for the built-in GCP fetchers.
+func NewFetcherRegistry() *FetcherRegistry {
+	return &FetcherRegistry{}
+}
+
+// Register appends f to the registry. Order matters: earlier fetchers are
+// tried first.
+func (r *FetcherRegistry) Register(f ResourceFetcher) {
+	r.fetchers = append(r.fetchers, f)
+}
+
+func (r *FetcherRegistry) resolve(resourceType, resourceName string) ResourceFetcher {
+	if r == nil {
+		return nil
+	}
+	for _, f := range r.fetchers {
+		if f.Matches(resourceType, resourceName) {
+			return f
+		}
+	}
+	return nil
+}
+
+// defaultFetcherRegistry builds the registry of built-in GCP fetchers,
+// sharing clients and the workload-project fallback list the same way the
+// old per-resource-type if/else chain in calculateStatistics used to.
+func defaultFetcherRegistry(clients *sharedEnrichmentClients, workloadProjects []string) *FetcherRegistry {
+	r := NewFetcherRegistry()
+	r.Register(vmwareFetcher{})
+	r.Register(gceInstanceFetcher{clients: clients, workloadProjects: workloadProjects})
+	r.Register(gceDiskFetcher{clients: clients, workloadProjects: workloadProjects})
+	r.Register(cloudSQLFetcher{clients: clients, workloadProjects: workloadProjects})
+	r.Register(filestoreFetcher{clients: clients, workloadProjects: workloadProjects})
+	r.Register(gcsBucketFetcher{clients: clients})
+	r.Register(bigQueryDatasetFetcher{clients: clients})
+	return r
+}
+
+// vmwareFetcher matches VMware VMs, which GCBDR doesn't report a disk total
+// for via any GCP API; it exists to take priority over gceInstanceFetcher's
+// "vm" substring match rather than to do any real lookup.
+type vmwareFetcher struct{}
+
+func (vmwareFetcher) Name() string { return "vmware" }
+
+func (vmwareFetcher) Matches(resourceType, _ string) bool {
+	return strings.Contains(resourceType, "vmware")
+}
+
+func (vmwareFetcher) FetchSizeBytes(_ context.Context, _, _ string) (int64, error) {
+	return 0, nil
+}
+
+// gceInstanceFetcher resolves a GCE instance's total attached-disk size.
+type gceInstanceFetcher struct {
+	clients          *sharedEnrichmentClients
+	workloadProjects []string
+}
+
+func (gceInstanceFetcher) Name() string { return "gce_instance" }
+
+func (f gceInstanceFetcher) Matches(resourceType, _ string) bool {
+	return strings.Contains(resourceType, "gce") || strings.Contains(resourceType, "compute") || strings.Contains(resourceType, "vm")
+}
+
+func (f gceInstanceFetcher) FetchSizeBytes(ctx context.Context, projectID, resourceName string) (int64, error) {
+	return f.clients.fetchGCEInstanceSize(ctx, f.workloadProjects, projectID, resourceName), nil
+}
+
+// gceDiskFetcher resolves a Persistent Disk's size, trying the zonal API
+// first and falling back to the regional one for regional PDs
+// (projects/*/regions/*/disks/*).
+type gceDiskFetcher struct {
+	clients          *sharedEnrichmentClients
+	workloadProjects []string
+}
+
+func (gceDiskFetcher) Name() string { return "gce_disk" }
+
+func (f gceDiskFetcher) Matches(resourceType, _ string) bool {
+	return strings.Contains(resourceType, "disk")
+}
+
+func (f gceDiskFetcher) FetchSizeBytes(ctx context.Context, projectID, resourceName string) (int64, error) {
+	if size := f.clients.fetchGCEDiskSize(ctx, f.workloadProjects, projectID, resourceName); size > 0 {
+		return size, nil
+	}
+	return f.clients.fetchRegionDiskSize(ctx, f.workloadProjects, projectID, resourceName), nil
+}
+
+// cloudSQLFetcher resolves a Cloud SQL instance's provisioned data disk size.
+type cloudSQLFetcher struct {
+	clients          *sharedEnrichmentClients
+	workloadProjects []string
+}
+
+func (cloudSQLFetcher) Name() string { return "cloud_sql" }
+
+func (f cloudSQLFetcher) Matches(resourceType, _ string) bool {
+	return strings.Contains(resourceType, "cloud sql")
+}
+
+func (f cloudSQLFetcher) FetchSizeBytes(ctx context.Context, projectID, resourceName string) (int64, error) {
+	return f.clients.fetchCloudSQLSize(ctx, f.workloadProjects, projectID, resourceName), nil
+}
+
+// filestoreFetcher resolves a Filestore instance's size as the sum of its
+// file shares' capacity.
+type filestoreFetcher struct {
+	clients          *sharedEnrichmentClients
+	workloadProjects []string
+}
+
+func (filestoreFetcher) Name() string { return "filestore" }
+
+func (f filestoreFetcher) Matches(resourceType, _ string) bool {
+	return strings.Contains(resourceType, "filestore")
+}
+
+func (f filestoreFetcher) FetchSizeBytes(ctx context.Context, projectID, resourceName string) (int64, error) {
+	return f.clients.fetchFilestoreSize(ctx, f.workloadProjects, projectID, resourceName), nil
+}
+
+// gcsBucketFetcher resolves a GCS bucket's size via the Cloud Monitoring
+// storage.googleapis.com/storage/total_bytes metric, since buckets have no
+// "get size" RPC of their own.
+type gcsBucketFetcher struct {
+	clients *sharedEnrichmentClients
+}
+
+func (gcsBucketFetcher) Name() string { return "gcs_bucket" }
+
+func (f gcsBucketFetcher) Matches(resourceType, _ string) bool {
+	return strings.Contains(resourceType, "gcs") || strings.Contains(resourceType, "bucket")
+}
+
+func (f gcsBucketFetcher) FetchSizeBytes(ctx context.Context, projectID, resourceName string) (int64, error) {
+	return f.clients.fetchGCSBucketSize(ctx, projectID, resourceName), nil
+}
+
+// bigQueryDatasetFetcher resolves a BigQuery dataset's size as the sum of
+// its tables' NumBytes.
+type bigQueryDatasetFetcher struct {
+	clients *sharedEnrichmentClients
+}
+
+func (bigQueryDatasetFetcher) Name() string { return "bigquery_dataset" }
+
+func (f bigQueryDatasetFetcher) Matches(resourceType, _ string) bool {
+	return strings.Contains(resourceType, "bigquery")
+}
+
+func (f bigQueryDatasetFetcher) FetchSizeBytes(ctx context.Context, projectID, resourceName string) (int64, error) {
+	return f.clients.fetchBigQueryDatasetSize(ctx, projectID, resourceName), nil
+}
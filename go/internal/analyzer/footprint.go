@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+)
+
+// GroupKeyFunc extracts a Footprint's group key from a job. GroupByProject,
+// GroupByJobSource and GroupByResourceType are the built-in groupings;
+// callers can supply their own (e.g. a tag parsed out of ResourceName) to
+// FootprintAggregator/Analyzer.Footprints for a custom rollup.
+type GroupKeyFunc func(JobData) string
+
+// GroupByProject groups jobs by the project the job ran against.
+func GroupByProject(j JobData) string { return j.ProjectID }
+
+// GroupByJobSource groups jobs by their source ("vault" or "appliance").
+func GroupByJobSource(j JobData) string { return j.JobSource }
+
+// GroupByResourceType groups jobs by their protected resource type.
+func GroupByResourceType(j JobData) string { return j.ResourceType }
+
+// FootprintWeight selects which per-job quantity is summed into a
+// Footprint's WeightedTotal, so a 10 TiB nightly backup isn't averaged flat
+// against a 100 GiB one when ranking groups by footprint.
+type FootprintWeight string
+
+const (
+	WeightJobCount       FootprintWeight = "job_count"
+	WeightResourceSizeGB FootprintWeight = "resource_size_gb"
+	WeightDurationHours  FootprintWeight = "duration_hours"
+)
+
+// Footprint is a grouped rollup of a set of jobs, e.g. all jobs in a
+// project or all jobs of a resource type, produced by FootprintAggregator /
+// Analyzer.Footprints.
+type Footprint struct {
+	Group string `json:"group"`
+
+	JobCount           int     `json:"job_count"`
+	TotalGiB           float64 `json:"total_gib"`
+	TotalDurationHours float64 `json:"total_duration_hours"`
+
+	// NodeHoursEquivalent is the sum, across the group's jobs, of each job's
+	// GiBTransferred times its duration in hours — a rough "how much work
+	// this group generated" figure borrowed from cc-backend's NodeHours.
+	NodeHoursEquivalent float64 `json:"node_hours_equivalent"`
+
+	// WeightedTotal sums weightFn(job) across the group, per the
+	// FootprintWeight passed to Analyzer.Footprints.
+	WeightedTotal float64 `json:"weighted_total"`
+
+	P50GiB             float64 `json:"p50_gib"`
+	P95GiB             float64 `json:"p95_gib"`
+	P50DurationSeconds float64 `json:"p50_duration_seconds"`
+	P95DurationSeconds float64 `json:"p95_duration_seconds"`
+
+	AnomalyCount int `json:"anomaly_count"`
+}
+
+// Footprints groups jobs by groupBy (defaulting to GroupByProject when nil)
+// and rolls each group up into a Footprint, weighting WeightedTotal by
+// weight (defaulting to WeightJobCount when empty). anomalies supplies each
+// group's AnomalyCount via a JobID lookup against jobs.
+func (a *Analyzer) Footprints(jobs []JobData, anomalies []Anomaly, groupBy GroupKeyFunc, weight FootprintWeight) []Footprint {
+	return FootprintAggregator(jobs, anomalies, groupBy, weight)
+}
+
+// FootprintAggregator is the standalone implementation behind
+// Analyzer.Footprints, split out so it can be exercised without an Analyzer.
+func FootprintAggregator(jobs []JobData, anomalies []Anomaly, groupBy GroupKeyFunc, weight FootprintWeight) []Footprint {
+	if groupBy == nil {
+		groupBy = GroupByProject
+	}
+
+	type groupAccumulator struct {
+		jobs []JobData
+	}
+	groups := make(map[string]*groupAccumulator)
+	var order []string
+
+	jobByID := make(map[string]JobData, len(jobs))
+	for _, j := range jobs {
+		if j.JobID != "" {
+			jobByID[j.JobID] = j
+		}
+
+		key := groupBy(j)
+		g, ok := groups[key]
+		if !ok {
+			g = &groupAccumulator{}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.jobs = append(g.jobs, j)
+	}
+
+	anomalyCounts := make(map[string]int)
+	for _, an := range anomalies {
+		job, ok := jobByID[an.JobID]
+		if !ok {
+			continue
+		}
+		anomalyCounts[groupBy(job)]++
+	}
+
+	footprints := make([]Footprint, 0, len(groups))
+	for _, key := range order {
+		g := groups[key]
+
+		fp := Footprint{
+			Group:        key,
+			JobCount:     len(g.jobs),
+			AnomalyCount: anomalyCounts[key],
+		}
+
+		gibValues := make([]float64, len(g.jobs))
+		durationValues := make([]float64, len(g.jobs))
+		for i, j := range g.jobs {
+			durationHours := j.DurationSeconds / 3600
+			fp.TotalGiB += j.GiBTransferred
+			fp.TotalDurationHours += durationHours
+			fp.NodeHoursEquivalent += j.GiBTransferred * durationHours
+			fp.WeightedTotal += footprintWeightValue(weight, j)
+			gibValues[i] = j.GiBTransferred
+			durationValues[i] = j.DurationSeconds
+		}
+
+		sort.Float64s(gibValues)
+		sort.Float64s(durationValues)
+		fp.P50GiB = percentileOfSorted(gibValues, 50)
+		fp.P95GiB = percentileOfSorted(gibValues, 95)
+		fp.P50DurationSeconds = percentileOfSorted(durationValues, 50)
+		fp.P95DurationSeconds = percentileOfSorted(durationValues, 95)
+
+		footprints = append(footprints, fp)
+	}
+
+	sort.Slice(footprints, func(i, j int) bool {
+		return footprints[i].Group < footprints[j].Group
+	})
+
+	return footprints
+}
+
+func footprintWeightValue(weight FootprintWeight, job JobData) float64 {
+	switch weight {
+	case WeightResourceSizeGB:
+		return float64(job.TotalResourceSizeBytes) / (1024 * 1024 * 1024) // bytes -> GB, matching calculateStatistics' TotalResourceSizeGB
+	case WeightDurationHours:
+		return job.DurationSeconds / 3600
+	default:
+		return 1
+	}
+}
+
+// percentileOfSorted returns the p-th percentile (0-100) of an
+// already-ascending-sorted slice, using the nearest-rank method.
+func percentileOfSorted(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,419 @@
+package analyzer
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"google.golang.org/api/iterator"
+
+	"cloud.google.com/go/logging/logadmin"
+)
+
+// defaultAnomalyWindowSize bounds how many of a resource's most recent jobs
+// the streaming pipeline keeps around for the MAD/EWMA anomaly checks. Jobs
+// older than that fall out of the ring buffer once the resource's baseline
+// stats have absorbed them.
+const defaultAnomalyWindowSize = 90
+
+// PipelineStage processes a stream of JobData, optionally enriching or
+// filtering it, and emits the result downstream. Implementations should
+// close their output channel once the input channel is drained or ctx is
+// cancelled.
+type PipelineStage interface {
+	Process(ctx context.Context, in <-chan JobData) <-chan JobData
+}
+
+// StageFunc adapts a plain function to a PipelineStage.
+type StageFunc func(ctx context.Context, in <-chan JobData) <-chan JobData
+
+// Process implements PipelineStage.
+func (f StageFunc) Process(ctx context.Context, in <-chan JobData) <-chan JobData {
+	return f(ctx, in)
+}
+
+// EnrichmentStage matches jobs against a GCB job map as they stream past,
+// the push-based equivalent of the blocking two-pass join Analyze performs
+// for appliance jobs today.
+type EnrichmentStage struct {
+	GCBJobs map[string]JobData
+}
+
+// Process implements PipelineStage.
+func (s *EnrichmentStage) Process(ctx context.Context, in <-chan JobData) <-chan JobData {
+	out := make(chan JobData)
+	go func() {
+		defer close(out)
+		for job := range in {
+			if gcbData, ok := s.GCBJobs[job.JobID]; ok {
+				if job.TotalResourceSizeBytes == 0 && gcbData.TotalResourceSizeBytes > 0 {
+					job.TotalResourceSizeBytes = gcbData.TotalResourceSizeBytes
+				}
+				if job.GiBTransferred == 0 && gcbData.GiBTransferred > 0 {
+					job.GiBTransferred = gcbData.GiBTransferred
+				}
+			}
+			select {
+			case out <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// fetchLogsStream is the streaming counterpart to fetchLogs: instead of
+// accumulating every entry into a slice before the caller can start working,
+// it pushes parsed jobs onto a channel as they're read off the log
+// iterator. Useful once Days is pushed out to 90 or WorkloadProjects spans
+// many projects, where the slice-based fetchLogs would hold everything in
+// memory before any stats could be computed.
+func (a *Analyzer) fetchLogsStream(ctx context.Context, filter, source string) <-chan JobData {
+	out := make(chan JobData)
+
+	go func() {
+		defer close(out)
+
+		a.LogDebug("DEBUG: [stream] Querying logs with filter: %s", filter)
+		it := a.client.Entries(ctx, logadmin.Filter(filter))
+
+		var entryCount, jobCount int
+		for {
+			entry, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				a.LogDebug("WARNING: [stream] failed to iterate %s logs: %v", source, err)
+				return
+			}
+			entryCount++
+
+			job := a.parseLogEntry(entry, source)
+			if job == nil {
+				continue
+			}
+			jobCount++
+
+			select {
+			case out <- *job:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		a.LogDebug("[stream] Fetched %d %s jobs (iterated %d entries)", jobCount, source, entryCount)
+	}()
+
+	return out
+}
+
+// fetchLogsStreamMulti fans fetchLogsStream out over every workload project
+// (falling back to []string{a.ProjectID} when none are configured, same as
+// Analyze) and merges their outputs onto one channel -- the streaming
+// counterpart to Analyze's ForEachJob-based per-project fan-out.
+func (a *Analyzer) fetchLogsStreamMulti(ctx context.Context, filterFor func(projectID string) string, source string) <-chan JobData {
+	projects := a.WorkloadProjects
+	if len(projects) == 0 {
+		projects = []string{a.ProjectID}
+	}
+
+	out := make(chan JobData)
+	var wg sync.WaitGroup
+	wg.Add(len(projects))
+	for _, projectID := range projects {
+		go func(projectID string) {
+			defer wg.Done()
+			for job := range a.fetchLogsStream(ctx, filterFor(projectID), source) {
+				select {
+				case out <- job:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(projectID)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// welfordAccumulator maintains a running mean/variance via Welford's online
+// algorithm, so the streaming stats path doesn't need the full value slice
+// in memory just to compute AvgGiB/StdDevGiB.
+type welfordAccumulator struct {
+	count int
+	mean  float64
+	m2    float64
+}
+
+func (w *welfordAccumulator) Add(x float64) {
+	w.count++
+	delta := x - w.mean
+	w.mean += delta / float64(w.count)
+	w.m2 += delta * (x - w.mean)
+}
+
+func (w *welfordAccumulator) Mean() float64 { return w.mean }
+
+func (w *welfordAccumulator) StdDev() float64 {
+	if w.count < 2 {
+		return 0
+	}
+	return math.Sqrt(w.m2 / float64(w.count))
+}
+
+// resourceWindow is a small ring buffer of a resource's most recent jobs,
+// large enough for the MAD/EWMA anomaly checks without retaining the
+// resource's entire history.
+type resourceWindow struct {
+	jobs   []JobData
+	cap    int
+	next   int
+	filled bool
+}
+
+func newResourceWindow(capacity int) *resourceWindow {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &resourceWindow{jobs: make([]JobData, capacity), cap: capacity}
+}
+
+func (w *resourceWindow) Add(job JobData) {
+	w.jobs[w.next] = job
+	w.next = (w.next + 1) % w.cap
+	if w.next == 0 {
+		w.filled = true
+	}
+}
+
+// Snapshot returns the buffered jobs in no particular order; callers that
+// need them time-ordered (e.g. the EWMA walk in detectAnomalies) already
+// sort by StartTime.
+func (w *resourceWindow) Snapshot() []JobData {
+	if !w.filled {
+		return append([]JobData(nil), w.jobs[:w.next]...)
+	}
+	out := make([]JobData, 0, w.cap)
+	for i := 0; i < w.cap; i++ {
+		out = append(out, w.jobs[(w.next+i)%w.cap])
+	}
+	return out
+}
+
+type incrementalResourceState struct {
+	resourceType  string
+	jobSource     string
+	gibWelford    welfordAccumulator
+	durWelford    welfordAccumulator
+	window        *resourceWindow
+	maxTotalBytes int64
+	jobCount      int
+}
+
+// incrementalResourceStats accumulates rolling sums (via Welford) and a
+// bounded per-resource window as jobs stream past, so the streaming
+// pipeline never needs the full []JobData slice in memory to compute
+// ResourceStats.
+type incrementalResourceStats struct {
+	mu         sync.Mutex
+	byResource map[string]*incrementalResourceState
+	windowSize int
+}
+
+func newIncrementalResourceStats(windowSize int) *incrementalResourceStats {
+	if windowSize <= 0 {
+		windowSize = defaultAnomalyWindowSize
+	}
+	return &incrementalResourceStats{
+		byResource: make(map[string]*incrementalResourceState),
+		windowSize: windowSize,
+	}
+}
+
+// Add folds a single streamed job into its resource's running state.
+func (s *incrementalResourceStats) Add(job JobData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.byResource[job.ResourceName]
+	if !ok {
+		st = &incrementalResourceState{
+			resourceType: job.ResourceType,
+			jobSource:    job.JobSource,
+			window:       newResourceWindow(s.windowSize),
+		}
+		s.byResource[job.ResourceName] = st
+	}
+
+	st.gibWelford.Add(job.GiBTransferred)
+	st.durWelford.Add(job.DurationSeconds)
+	st.window.Add(job)
+	st.jobCount++
+	if job.TotalResourceSizeBytes > st.maxTotalBytes {
+		st.maxTotalBytes = job.TotalResourceSizeBytes
+	}
+}
+
+// Finalize reduces the accumulated state down to the same []ResourceStats
+// shape calculateStatistics produces, plus the bounded job windows needed to
+// run detectAnomalies without re-reading the original (unbounded) stream.
+func (s *incrementalResourceStats) Finalize(days int) ([]ResourceStats, map[string][]JobData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stats []ResourceStats
+	windows := make(map[string][]JobData, len(s.byResource))
+
+	for name, st := range s.byResource {
+		windowJobs := st.window.Snapshot()
+		sort.Slice(windowJobs, func(i, j int) bool {
+			return windowJobs[i].StartTime.Before(windowJobs[j].StartTime)
+		})
+		gibValues := make([]float64, len(windowJobs))
+		durationValues := make([]float64, len(windowJobs))
+		for i, j := range windowJobs {
+			gibValues[i] = j.GiBTransferred
+			durationValues[i] = j.DurationSeconds
+		}
+		medianGiB, madGiB := medianAndMAD(gibValues)
+		medianDuration, madDuration := medianAndMAD(durationValues)
+		ewmaGiB, ewmsdGiB := ewmaState(gibValues, ewmaAlpha)
+
+		totalResourceSizeGB := float64(st.maxTotalBytes) / (1024 * 1024 * 1024)
+		dailyChangeGB := (st.gibWelford.Mean() * float64(st.jobCount) * 1.073741824) / float64(days)
+
+		var dailyChangePct float64
+		if totalResourceSizeGB > 0 {
+			dailyChangePct = (dailyChangeGB / totalResourceSizeGB) * 100
+		}
+
+		stats = append(stats, ResourceStats{
+			ResourceName:          name,
+			ResourceType:          st.resourceType,
+			JobSource:             st.jobSource,
+			TotalResourceSizeGB:   totalResourceSizeGB,
+			CurrentDailyChangeGB:  dailyChangeGB,
+			CurrentDailyChangePct: dailyChangePct,
+			BackupJobCount:        st.jobCount,
+			AvgGiB:                st.gibWelford.Mean(),
+			StdDevGiB:             st.gibWelford.StdDev(),
+			AvgDurationSeconds:    st.durWelford.Mean(),
+			StdDevDuration:        st.durWelford.StdDev(),
+			MedianGiB:             medianGiB,
+			MADGiB:                madGiB,
+			MedianDurationSeconds: medianDuration,
+			MADDurationSeconds:    madDuration,
+			EWMAGiB:               ewmaGiB,
+			EWMSDGiB:              ewmsdGiB,
+		})
+		windows[name] = windowJobs
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ResourceName < stats[j].ResourceName })
+	return stats, windows
+}
+
+// AnalyzeStreaming is a memory-bounded alternative to Analyze for large Days
+// windows or many workload projects. Logs are streamed job-by-job through a
+// small pipeline (vault-stream, appliance-stream=>enricher=>stats,
+// GCB-stream=>cache) instead of being collected into slices up front; only
+// the reduced ResourceStats/Anomaly slices need to live in memory once the
+// stream drains.
+func (a *Analyzer) AnalyzeStreaming(ctx context.Context, filterName, sourceType string) (*AnalysisResult, error) {
+	result := &AnalysisResult{DebugMessages: []string{}}
+	result.Summary.ProjectID = a.ProjectID
+	defer func() {
+		result.DebugMessages = append(result.DebugMessages, a.DebugLog...)
+	}()
+
+	filterStage := StageFunc(func(ctx context.Context, in <-chan JobData) <-chan JobData {
+		out := make(chan JobData)
+		go func() {
+			defer close(out)
+			for job := range in {
+				if !matchesResourceFilter(job.ResourceName, filterName) {
+					continue
+				}
+				select {
+				case out <- job:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return out
+	})
+
+	var vaultWindows, applianceWindows map[string][]JobData
+
+	if sourceType == "all" || sourceType == "vault" {
+		stream := filterStage.Process(ctx, a.fetchLogsStreamMulti(ctx, a.vaultLogFilter, "vault"))
+
+		acc := newIncrementalResourceStats(defaultAnomalyWindowSize)
+		var count int
+		for job := range stream {
+			acc.Add(job)
+			count++
+		}
+
+		stats, windows := acc.Finalize(a.Days)
+		result.VaultWorkloads.ResourceStats = stats
+		result.Summary.TotalVaultJobs = count
+		vaultWindows = windows
+	}
+
+	if sourceType == "all" || sourceType == "appliance" {
+		gcbJobs := make(map[string]JobData)
+		for job := range a.fetchLogsStreamMulti(ctx, a.gcbLogFilter, "gcb") {
+			if job.JobID != "" {
+				gcbJobs[job.JobID] = job
+			}
+		}
+
+		enricher := &EnrichmentStage{GCBJobs: gcbJobs}
+		stream := filterStage.Process(ctx, enricher.Process(ctx, a.fetchLogsStreamMulti(ctx, a.applianceLogFilter, "appliance")))
+
+		acc := newIncrementalResourceStats(defaultAnomalyWindowSize)
+		var count int
+		for job := range stream {
+			acc.Add(job)
+			count++
+		}
+
+		stats, windows := acc.Finalize(a.Days)
+		result.ApplianceWorkloads.ResourceStats = stats
+		result.Summary.TotalApplianceJobs = count
+		applianceWindows = windows
+	}
+
+	allStats := append(result.VaultWorkloads.ResourceStats, result.ApplianceWorkloads.ResourceStats...)
+
+	for _, windows := range []map[string][]JobData{vaultWindows, applianceWindows} {
+		for _, windowJobs := range windows {
+			result.Anomalies = append(result.Anomalies, a.detectAnomalies(windowJobs, allStats)...)
+		}
+	}
+
+	var totalSizeGB, dailyChangeGB float64
+	for _, s := range allStats {
+		totalSizeGB += s.TotalResourceSizeGB
+		dailyChangeGB += s.CurrentDailyChangeGB
+	}
+	result.Summary.TotalResourceSizeGB = totalSizeGB
+	result.Summary.CurrentDailyChangeGB = dailyChangeGB
+	if totalSizeGB > 0 {
+		result.Summary.CurrentDailyChangePct = (dailyChangeGB / totalSizeGB) * 100
+	}
+	result.Summary.TotalJobs = result.Summary.TotalVaultJobs + result.Summary.TotalApplianceJobs
+	result.Summary.AnomalyCount = len(result.Anomalies)
+
+	return result, nil
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"sort"
 	"strings"
 
 	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/analyzer"
@@ -44,7 +45,7 @@ func FormatCSV(result *analyzer.AnalysisResult) ([]byte, error) {
 	if len(result.Anomalies) > 0 {
 		w.Write([]string{})
 		w.Write([]string{"ANOMALIES DETECTED"})
-		w.Write([]string{"Job ID", "Resource", "Date", "Time", "Change (GB)", "Avg (GB)", "Duration (s)", "Avg Duration (s)", "Reasons"})
+		w.Write([]string{"Job ID", "Resource", "Date", "Time", "Finish Time", "Change (GB)", "Avg (GB)", "Duration", "Avg Duration", "Reasons"})
 
 		for _, a := range result.Anomalies {
 			w.Write([]string{
@@ -52,15 +53,35 @@ func FormatCSV(result *analyzer.AnalysisResult) ([]byte, error) {
 				a.Resource,
 				a.Date,
 				a.Time,
+				a.FinishTime,
 				fmt.Sprintf("%.2f", a.GiBTransferred),
 				fmt.Sprintf("%.2f", a.AvgGiB),
-				fmt.Sprintf("%.0f", a.DurationSeconds),
-				fmt.Sprintf("%.1f", a.AvgDurationSeconds),
+				analyzer.FormatDuration(a.DurationSeconds),
+				analyzer.FormatDuration(a.AvgDurationSeconds),
 				strings.Join(a.Reasons, ", "),
 			})
 		}
 	}
 
+	// Footprints Section
+	if len(result.Footprints) > 0 {
+		w.Write([]string{})
+		w.Write([]string{"FOOTPRINTS"})
+		w.Write([]string{"Group", "Job Count", "Total (GiB)", "Total Duration (Hours)", "Node-Hours Equivalent", "Weighted Total", "Anomaly Count"})
+
+		for _, f := range result.Footprints {
+			w.Write([]string{
+				f.Group,
+				fmt.Sprintf("%d", f.JobCount),
+				fmt.Sprintf("%.2f", f.TotalGiB),
+				fmt.Sprintf("%.2f", f.TotalDurationHours),
+				fmt.Sprintf("%.2f", f.NodeHoursEquivalent),
+				fmt.Sprintf("%.2f", f.WeightedTotal),
+				fmt.Sprintf("%d", f.AnomalyCount),
+			})
+		}
+	}
+
 	// Daily Baselines Section
 	if len(result.DailyBaselines) > 0 {
 		w.Write([]string{})
@@ -146,15 +167,16 @@ const htmlTemplate = `<!DOCTYPE html>
     {{if .Anomalies}}
     <h2>⚠️ Anomalies Detected</h2>
     <table>
-        <tr><th>Job ID</th><th>Resource</th><th>Date/Time</th><th>Change (GB)</th><th>Avg (GB)</th><th>Duration (s)</th><th>Reasons</th></tr>
+        <tr><th>Job ID</th><th>Resource</th><th>Start</th><th>Finish</th><th>Change (GB)</th><th>Avg (GB)</th><th>Duration</th><th>Reasons</th></tr>
         {{range .Anomalies}}
         <tr class="anomaly">
             <td>{{.JobID}}</td>
             <td>{{.Resource}}</td>
             <td>{{.Date}} {{.Time}}</td>
+            <td>{{.FinishTime}}</td>
             <td>{{printf "%.2f" .GiBTransferred}}</td>
             <td>{{printf "%.2f" .AvgGiB}}</td>
-            <td>{{printf "%.0f" .DurationSeconds}}</td>
+            <td>{{formatDuration .DurationSeconds}}</td>
             <td>{{range .Reasons}}{{.}}<br>{{end}}</td>
         </tr>
         {{end}}
@@ -177,7 +199,25 @@ const htmlTemplate = `<!DOCTYPE html>
         {{end}}
     </table>
 
-    
+
+    {{if .Footprints}}
+    <h2>Footprints</h2>
+    <table>
+        <tr><th>Group</th><th>Job Count</th><th>Total (GiB)</th><th>Total Duration (Hours)</th><th>Node-Hours Equivalent</th><th>Weighted Total</th><th>Anomaly Count</th></tr>
+        {{range .Footprints}}
+        <tr>
+            <td>{{.Group}}</td>
+            <td>{{.JobCount}}</td>
+            <td>{{printf "%.2f" .TotalGiB}}</td>
+            <td>{{printf "%.2f" .TotalDurationHours}}</td>
+            <td>{{printf "%.2f" .NodeHoursEquivalent}}</td>
+            <td>{{printf "%.2f" .WeightedTotal}}</td>
+            <td>{{.AnomalyCount}}</td>
+        </tr>
+        {{end}}
+    </table>
+    {{end}}
+
     {{if .DailyBaselines}}
     <h2>Daily Baseline Metrics</h2>
     <table>
@@ -216,11 +256,12 @@ type HTMLData struct {
 	Anomalies      []analyzer.Anomaly
 	DailyBaselines []analyzer.DailyBaseline
 	AllStats       []analyzer.ResourceStats
+	Footprints     []analyzer.Footprint
 }
 
 // FormatHTML formats the result as HTML.
 func FormatHTML(result *analyzer.AnalysisResult) ([]byte, error) {
-	tmpl, err := template.New("report").Parse(htmlTemplate)
+	tmpl, err := template.New("report").Funcs(template.FuncMap{"formatDuration": analyzer.FormatDuration}).Parse(htmlTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("template parse error: %w", err)
 	}
@@ -232,6 +273,7 @@ func FormatHTML(result *analyzer.AnalysisResult) ([]byte, error) {
 		Anomalies:      result.Anomalies,
 		DailyBaselines: result.DailyBaselines,
 		AllStats:       allStats,
+		Footprints:     result.Footprints,
 	}
 
 	var buf bytes.Buffer
@@ -241,3 +283,154 @@ func FormatHTML(result *analyzer.AnalysisResult) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+const digestTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>GCBDR Monitor Digest</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; margin: 20px; background: #1a1a2e; color: #eee; }
+        h1, h2 { color: #00d9ff; }
+        table { border-collapse: collapse; width: 100%; margin: 20px 0; }
+        th, td { padding: 12px; text-align: left; border: 1px solid #333; }
+        th { background: #16213e; color: #00d9ff; }
+        tr:nth-child(even) { background: #0f0f23; }
+        .summary { display: flex; flex-wrap: wrap; gap: 20px; margin-bottom: 20px; }
+        .stat-card { background: #16213e; padding: 20px; border-radius: 8px; min-width: 150px; }
+        .stat-card h3 { margin: 0; color: #00d9ff; font-size: 2em; }
+        .stat-card p { margin: 5px 0 0; color: #888; }
+        .suspicious { color: #ff9800; }
+    </style>
+</head>
+<body>
+    <h1>GCBDR Monitor Digest &mdash; last {{.Days}} days</h1>
+
+    <div class="summary">
+        <div class="stat-card">
+            <h3>{{.Summary.TotalJobs}}</h3>
+            <p>Total Jobs</p>
+        </div>
+        <div class="stat-card">
+            <h3 style="color: #ff9800;">{{.Summary.AnomalyCount}}</h3>
+            <p>Anomalies</p>
+        </div>
+        <div class="stat-card">
+            <h3>{{printf "%.2f" .Summary.TotalResourceSizeGB}}</h3>
+            <p>Total Protected (GB)</p>
+        </div>
+        <div class="stat-card">
+            <h3>{{printf "%.2f" .Summary.CurrentDailyChangeGB}}</h3>
+            <p>Daily Change (GB) ({{printf "%.2f" .Summary.CurrentDailyChangePct}}%)</p>
+        </div>
+    </div>
+
+    <h2>Top Anomalous Resources</h2>
+    {{if .TopAnomalous}}
+    <table>
+        <tr><th>Resource</th><th>Anomalies (last {{.Days}} days)</th></tr>
+        {{range .TopAnomalous}}
+        <tr><td>{{.Resource}}</td><td>{{.Count}}</td></tr>
+        {{end}}
+    </table>
+    {{else}}
+    <p>No anomalies in this window.</p>
+    {{end}}
+
+    <h2>Daily Trend</h2>
+    {{if .DailyBaselines}}
+    <table>
+        <tr>
+            <th>Date</th>
+            <th>New (GB)</th>
+            <th>Deleted (GB)</th>
+            <th>Suspicious (GB)</th>
+            <th>Total Protected (GB)</th>
+            <th>Resources</th>
+        </tr>
+        {{range .DailyBaselines}}
+        <tr>
+            <td>{{.Date}}</td>
+            <td>{{printf "%.2f" .NewDataGB}}</td>
+            <td>{{printf "%.2f" .DeletedDataGB}}</td>
+            <td class="{{if .SuspiciousDataGB}}suspicious{{end}}">{{printf "%.2f" .SuspiciousDataGB}}</td>
+            <td>{{printf "%.2f" .TotalProtectedGB}}</td>
+            <td>
+                {{.ResourceCount}}
+                {{if .NewResourceCount}}(+{{.NewResourceCount}}){{end}}
+                {{if .DeletedResourceCount}}(-{{.DeletedResourceCount}}){{end}}
+            </td>
+        </tr>
+        {{end}}
+    </table>
+    {{else}}
+    <p>No daily baseline data in this window.</p>
+    {{end}}
+</body>
+</html>`
+
+// TopAnomalousResource is one row of FormatDigest's "top anomalous
+// resources" table: how many times a resource triggered an anomaly over
+// the digest window.
+type TopAnomalousResource struct {
+	Resource string
+	Count    int
+}
+
+// DigestData is the data structure for the digest template.
+type DigestData struct {
+	Summary        analyzer.Summary
+	TopAnomalous   []TopAnomalousResource
+	DailyBaselines []analyzer.DailyBaseline
+	Days           int
+}
+
+// FormatDigest renders a periodic baseline/activity report: the top
+// anomalous resources, the new/deleted resource counts and total protected
+// GB trend per day, and any suspicious-growth days. Unlike FormatHTML,
+// it's meant to be sent on a schedule even when there are no anomalies, so
+// operators get a regular baseline report rather than only alerts.
+func FormatDigest(result *analyzer.AnalysisResult, days int) ([]byte, error) {
+	tmpl, err := template.New("digest").Parse(digestTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("digest template parse error: %w", err)
+	}
+
+	data := DigestData{
+		Summary:        result.Summary,
+		TopAnomalous:   topAnomalousResources(result.Anomalies, 5),
+		DailyBaselines: result.DailyBaselines,
+		Days:           days,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("digest template execute error: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// topAnomalousResources counts how many anomalies each resource triggered
+// and returns the top n, most-frequent first, in first-seen order among
+// ties.
+func topAnomalousResources(anomalies []analyzer.Anomaly, n int) []TopAnomalousResource {
+	counts := make(map[string]int)
+	var order []string
+	for _, a := range anomalies {
+		if _, ok := counts[a.Resource]; !ok {
+			order = append(order, a.Resource)
+		}
+		counts[a.Resource]++
+	}
+
+	top := make([]TopAnomalousResource, 0, len(order))
+	for _, r := range order {
+		top = append(top, TopAnomalousResource{Resource: r, Count: counts[r]})
+	}
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Count > top[j].Count })
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/analyzer"
+)
+
+// DefaultCacheTTL is how long Cache trusts a cached AnalysisResult before
+// calling fetch again, when Cache.TTL is zero.
+const DefaultCacheTTL = 60 * time.Second
+
+// Cache memoizes the most recent AnalysisResult behind a TTL, so the
+// /metrics endpoint -- likely scraped by Prometheus every 15-30s -- doesn't
+// re-run Analyze, and hammer the BigQuery/Cloud Asset APIs, on every
+// scrape. Wired to METRICS_CACHE_TTL by cmd/gcbdr-monitor.
+type Cache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	result  *analyzer.AnalysisResult
+	fetched time.Time
+}
+
+// Get returns the cached result if it's younger than TTL, otherwise calls
+// fetch, caches its result, and returns that instead. A fetch error is
+// never cached, so the next call retries.
+func (c *Cache) Get(fetch func() (*analyzer.AnalysisResult, error)) (*analyzer.AnalysisResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.TTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	if c.result != nil && time.Since(c.fetched) < ttl {
+		return c.result, nil
+	}
+
+	result, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	c.result = result
+	c.fetched = time.Now()
+	return c.result, nil
+}
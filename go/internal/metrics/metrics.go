@@ -0,0 +1,108 @@
+// Package metrics renders an analyzer.AnalysisResult as Prometheus
+// text-exposition-format metrics, so operators can scrape /metrics and
+// define their own Prometheus/Alertmanager routing and alerting rules
+// instead of relying solely on the built-in notifier channels.
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/analyzer"
+)
+
+// Render formats result as Prometheus text-exposition-format metrics.
+func Render(result *analyzer.AnalysisResult) []byte {
+	var sb strings.Builder
+
+	writeJobsTotal(&sb, result)
+	writeAnomaliesTotal(&sb, result)
+	writeResourceGauges(&sb, result)
+	writeBaselineGauges(&sb, result)
+
+	return []byte(sb.String())
+}
+
+func writeJobsTotal(sb *strings.Builder, result *analyzer.AnalysisResult) {
+	sb.WriteString("# HELP gcbdr_jobs_total Total backup jobs observed, by status.\n")
+	sb.WriteString("# TYPE gcbdr_jobs_total counter\n")
+	fmt.Fprintf(sb, "gcbdr_jobs_total{status=%q} %d\n", "success", result.Summary.SuccessfulJobs)
+	fmt.Fprintf(sb, "gcbdr_jobs_total{status=%q} %d\n", "failed", result.Summary.FailedJobs)
+}
+
+func writeAnomaliesTotal(sb *strings.Builder, result *analyzer.AnalysisResult) {
+	sb.WriteString("# HELP gcbdr_anomalies_total Anomalies detected, by resource and reason.\n")
+	sb.WriteString("# TYPE gcbdr_anomalies_total counter\n")
+
+	type key struct{ resource, reason string }
+	counts := make(map[key]int)
+	var order []key
+	for _, a := range result.Anomalies {
+		for _, reason := range a.Reasons {
+			k := key{a.Resource, reason}
+			if _, ok := counts[k]; !ok {
+				order = append(order, k)
+			}
+			counts[k]++
+		}
+	}
+	for _, k := range order {
+		fmt.Fprintf(sb, "gcbdr_anomalies_total{resource=%s,reason=%s} %d\n", labelValue(k.resource), labelValue(k.reason), counts[k])
+	}
+}
+
+func writeResourceGauges(sb *strings.Builder, result *analyzer.AnalysisResult) {
+	sb.WriteString("# HELP gcbdr_resource_size_gib Current total resource size in GiB, by resource.\n")
+	sb.WriteString("# TYPE gcbdr_resource_size_gib gauge\n")
+
+	allStats := append(append([]analyzer.ResourceStats{}, result.VaultWorkloads.ResourceStats...), result.ApplianceWorkloads.ResourceStats...)
+	for _, r := range allStats {
+		fmt.Fprintf(sb, "gcbdr_resource_size_gib{resource=%s,type=%s,source=%s} %s\n",
+			labelValue(r.ResourceName), labelValue(r.ResourceType), labelValue(r.JobSource), formatFloat(r.TotalResourceSizeGB))
+	}
+
+	sb.WriteString("# HELP gcbdr_daily_change_gib Current daily size change in GiB, by resource.\n")
+	sb.WriteString("# TYPE gcbdr_daily_change_gib gauge\n")
+	for _, r := range allStats {
+		// CurrentDailyChangeGB is in GB; convert back to GiB for this metric.
+		fmt.Fprintf(sb, "gcbdr_daily_change_gib{resource=%s} %s\n", labelValue(r.ResourceName), formatFloat(r.CurrentDailyChangeGB/1.073741824))
+	}
+}
+
+func writeBaselineGauges(sb *strings.Builder, result *analyzer.AnalysisResult) {
+	gauges := []struct {
+		name string
+		help string
+		val  func(analyzer.DailyBaseline) float64
+	}{
+		{"gcbdr_modified_gb", "Modified data in GB, by date.", func(b analyzer.DailyBaseline) float64 { return b.ModifiedDataGB }},
+		{"gcbdr_new_gb", "New data in GB, by date.", func(b analyzer.DailyBaseline) float64 { return b.NewDataGB }},
+		{"gcbdr_deleted_gb", "Deleted data in GB, by date.", func(b analyzer.DailyBaseline) float64 { return b.DeletedDataGB }},
+		{"gcbdr_suspicious_gb", "Suspicious data growth in GB, by date.", func(b analyzer.DailyBaseline) float64 { return b.SuspiciousDataGB }},
+		{"gcbdr_total_protected_gb", "Total protected data in GB, by date.", func(b analyzer.DailyBaseline) float64 { return b.TotalProtectedGB }},
+	}
+
+	for _, g := range gauges {
+		fmt.Fprintf(sb, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(sb, "# TYPE %s gauge\n", g.name)
+		for _, b := range result.DailyBaselines {
+			fmt.Fprintf(sb, "%s{date=%s} %s\n", g.name, labelValue(b.Date), formatFloat(g.val(b)))
+		}
+	}
+}
+
+// labelValue quotes and escapes v for use as a Prometheus label value.
+func labelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return `"` + v + `"`
+}
+
+// formatFloat renders a metric value the way the Prometheus exposition
+// format expects (no trailing zeros beyond what's needed, no exponent for
+// ordinary magnitudes).
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
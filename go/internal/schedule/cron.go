@@ -0,0 +1,130 @@
+// Package schedule implements a minimal standard 5-field cron expression
+// evaluator ("minute hour day-of-month month day-of-week"), just enough to
+// drive DigestNotifier's periodic run from cmd/gcbdr-monitor without taking
+// on an external cron dependency.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type field struct {
+	wildcard bool
+	values   map[int]bool
+}
+
+func (f field) matches(v int) bool {
+	return f.wildcard || f.values[v]
+}
+
+// NextAfter returns the next time expr matches strictly after from, in
+// from's location. expr is a standard 5-field cron expression supporting
+// "*", "N", "N,M,...", "N-M" and "*/N" (and "N-M/S") per field.
+func NextAfter(expr string, from time.Time) (time.Time, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return time.Time{}, fmt.Errorf("cron expression must have 5 fields (minute hour dom month dow), got %d: %q", len(parts), expr)
+	}
+
+	minute, err := parseField(parts[0], 0, 59)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("minute field: %w", err)
+	}
+	hour, err := parseField(parts[1], 0, 23)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("hour field: %w", err)
+	}
+	dom, err := parseField(parts[2], 1, 31)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("day-of-month field: %w", err)
+	}
+	month, err := parseField(parts[3], 1, 12)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("month field: %w", err)
+	}
+	dow, err := parseField(parts[4], 0, 6)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("day-of-week field: %w", err)
+	}
+
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(1, 0, 0)
+	for t.Before(limit) {
+		if month.matches(int(t.Month())) && domMatches(dom, dow, t) && hour.matches(t.Hour()) && minute.matches(t.Minute()) {
+			return t, nil
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}, fmt.Errorf("no match found for cron expression %q within a year of %s", expr, from)
+}
+
+// domMatches applies cron's "OR" rule for day fields: if both
+// day-of-month and day-of-week are restricted (non-wildcard), a day
+// matching either one counts; if only one is restricted, that one alone
+// decides.
+func domMatches(dom, dow field, t time.Time) bool {
+	if dom.wildcard && dow.wildcard {
+		return true
+	}
+	if dom.wildcard {
+		return dow.matches(int(t.Weekday()))
+	}
+	if dow.wildcard {
+		return dom.matches(t.Day())
+	}
+	return dom.matches(t.Day()) || dow.matches(int(t.Weekday()))
+}
+
+func parseField(raw string, min, max int) (field, error) {
+	if raw == "*" {
+		return field{wildcard: true}, nil
+	}
+
+	values := make(map[int]bool)
+	for _, part := range strings.Split(raw, ",") {
+		rangePart, step, err := splitStep(part)
+		if err != nil {
+			return field{}, err
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			lo, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return field{}, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				hi, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return field{}, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return field{}, fmt.Errorf("value out of range [%d,%d]: %q", min, max, part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+
+	return field{values: values}, nil
+}
+
+func splitStep(part string) (rangePart string, step int, err error) {
+	pieces := strings.SplitN(part, "/", 2)
+	if len(pieces) == 1 {
+		return pieces[0], 1, nil
+	}
+	step, err = strconv.Atoi(pieces[1])
+	if err != nil || step <= 0 {
+		return "", 0, fmt.Errorf("invalid step in %q", part)
+	}
+	return pieces[0], step, nil
+}
@@ -0,0 +1,163 @@
+// Package state tracks the lifecycle of detected anomalies across Analyze
+// runs -- new, ongoing, or resolved -- and lets operators ack a noisy
+// resource so notifications stay quiet until a set time, the way
+// Alertmanager's silence/resolve semantics work on top of raw alerts.
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/analyzer"
+)
+
+// Classification labels how Tracker.Classify sees one anomaly relative to
+// the stored state from the previous pass.
+type Classification string
+
+const (
+	New      Classification = "new"
+	Ongoing  Classification = "ongoing"
+	Resolved Classification = "resolved"
+)
+
+// AnomalyState is the persisted record for one fingerprint, carrying
+// enough history for Tracker to classify future Analyze passes and for an
+// ack to suppress notifications until AckUntil.
+type AnomalyState struct {
+	Fingerprint string           `json:"fingerprint"`
+	FirstSeen   time.Time        `json:"first_seen"`
+	LastSeen    time.Time        `json:"last_seen"`
+	ResolvedAt  *time.Time       `json:"resolved_at,omitempty"`
+	AckUntil    *time.Time       `json:"ack_until,omitempty"`
+	Anomaly     analyzer.Anomaly `json:"anomaly"`
+}
+
+// ClassifiedAnomaly pairs an anomaly's fingerprint and lifecycle
+// Classification with the anomaly itself, so notifier.Manager can render
+// "fired" vs "resolved" messages instead of re-alerting on every pass.
+type ClassifiedAnomaly struct {
+	Fingerprint    string
+	Classification Classification
+	Anomaly        analyzer.Anomaly
+	// Acked is true when an unexpired ack should suppress notification for
+	// this fingerprint.
+	Acked bool
+}
+
+// Fingerprint derives a stable identity for an anomaly from its JobID,
+// Resource, Date and sorted Reasons, so the same anomaly reported across
+// Analyze runs -- where Reasons order isn't guaranteed -- maps to the same
+// state record.
+func Fingerprint(a analyzer.Anomaly) string {
+	reasons := append([]string{}, a.Reasons...)
+	sort.Strings(reasons)
+	sum := sha256.Sum256([]byte(a.JobID + "|" + a.Resource + "|" + a.Date + "|" + strings.Join(reasons, ",")))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Tracker classifies each Analyze pass's anomalies against a Store-backed
+// history of fingerprints.
+type Tracker struct {
+	Store Store
+}
+
+// NewTracker returns a Tracker backed by store.
+func NewTracker(store Store) *Tracker {
+	return &Tracker{Store: store}
+}
+
+// Classify loads the stored state, compares it against current, persists
+// the updated state, and returns one ClassifiedAnomaly per current anomaly
+// (New or Ongoing) plus one per previously-open fingerprint no longer
+// present in current (Resolved).
+func (t *Tracker) Classify(current []analyzer.Anomaly) ([]ClassifiedAnomaly, error) {
+	stateMap, err := t.Store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("state: failed to load anomaly state: %w", err)
+	}
+	if stateMap == nil {
+		stateMap = make(map[string]AnomalyState)
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(current))
+	var result []ClassifiedAnomaly
+
+	for _, a := range current {
+		fp := Fingerprint(a)
+		seen[fp] = true
+
+		existing, ok := stateMap[fp]
+
+		classification := New
+		if ok && existing.ResolvedAt == nil {
+			classification = Ongoing
+		}
+
+		firstSeen := now
+		var ackUntil *time.Time
+		if ok {
+			firstSeen = existing.FirstSeen
+			ackUntil = existing.AckUntil
+		}
+
+		stateMap[fp] = AnomalyState{
+			Fingerprint: fp,
+			FirstSeen:   firstSeen,
+			LastSeen:    now,
+			AckUntil:    ackUntil,
+			Anomaly:     a,
+		}
+
+		result = append(result, ClassifiedAnomaly{
+			Fingerprint:    fp,
+			Classification: classification,
+			Anomaly:        a,
+			Acked:          ackUntil != nil && ackUntil.After(now),
+		})
+	}
+
+	for fp, existing := range stateMap {
+		if seen[fp] || existing.ResolvedAt != nil {
+			continue
+		}
+		resolvedAt := now
+		existing.ResolvedAt = &resolvedAt
+		stateMap[fp] = existing
+		result = append(result, ClassifiedAnomaly{
+			Fingerprint:    fp,
+			Classification: Resolved,
+			Anomaly:        existing.Anomaly,
+		})
+	}
+
+	if err := t.Store.Save(stateMap); err != nil {
+		return nil, fmt.Errorf("state: failed to save anomaly state: %w", err)
+	}
+
+	return result, nil
+}
+
+// Ack suppresses notifications for fingerprint until ackUntil. Returns an
+// error if fingerprint has no recorded state (e.g. Classify has never seen
+// it).
+func (t *Tracker) Ack(fingerprint string, ackUntil time.Time) error {
+	stateMap, err := t.Store.Load()
+	if err != nil {
+		return fmt.Errorf("state: failed to load anomaly state: %w", err)
+	}
+
+	existing, ok := stateMap[fingerprint]
+	if !ok {
+		return fmt.Errorf("state: unknown fingerprint %q", fingerprint)
+	}
+
+	existing.AckUntil = &ackUntil
+	stateMap[fingerprint] = existing
+	return t.Store.Save(stateMap)
+}
@@ -0,0 +1,102 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeffoconnorau/gcbdr_monitor/go/internal/analyzer"
+)
+
+// memStore is a minimal in-memory Store for exercising Tracker without
+// touching the filesystem or a real GCS/Firestore backend.
+type memStore struct {
+	states map[string]AnomalyState
+}
+
+func newMemStore() *memStore {
+	return &memStore{states: make(map[string]AnomalyState)}
+}
+
+func (m *memStore) Load() (map[string]AnomalyState, error) {
+	out := make(map[string]AnomalyState, len(m.states))
+	for k, v := range m.states {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (m *memStore) Save(states map[string]AnomalyState) error {
+	m.states = states
+	return nil
+}
+
+func TestFingerprintStableAcrossReasonOrder(t *testing.T) {
+	a := analyzer.Anomaly{JobID: "job-1", Resource: "res-1", Date: "2026-07-26", Reasons: []string{"Size Spike", "Duration Spike"}}
+	b := a
+	b.Reasons = []string{"Duration Spike", "Size Spike"}
+
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Errorf("Fingerprint() differs for the same anomaly with reordered Reasons")
+	}
+
+	c := a
+	c.Resource = "res-2"
+	if Fingerprint(a) == Fingerprint(c) {
+		t.Errorf("Fingerprint() collided for two anomalies on different resources")
+	}
+}
+
+func TestTrackerClassifyLifecycle(t *testing.T) {
+	tracker := NewTracker(newMemStore())
+	anomaly := analyzer.Anomaly{JobID: "job-1", Resource: "res-1", Date: "2026-07-26", Reasons: []string{"Size Spike"}}
+
+	classified, err := tracker.Classify([]analyzer.Anomaly{anomaly})
+	if err != nil {
+		t.Fatalf("Classify() first pass error: %v", err)
+	}
+	if len(classified) != 1 || classified[0].Classification != New {
+		t.Fatalf("Classify() first pass = %+v, want one New classification", classified)
+	}
+
+	classified, err = tracker.Classify([]analyzer.Anomaly{anomaly})
+	if err != nil {
+		t.Fatalf("Classify() second pass error: %v", err)
+	}
+	if len(classified) != 1 || classified[0].Classification != Ongoing {
+		t.Fatalf("Classify() second pass = %+v, want one Ongoing classification", classified)
+	}
+
+	classified, err = tracker.Classify(nil)
+	if err != nil {
+		t.Fatalf("Classify() third pass error: %v", err)
+	}
+	if len(classified) != 1 || classified[0].Classification != Resolved {
+		t.Fatalf("Classify() third pass (anomaly cleared) = %+v, want one Resolved classification", classified)
+	}
+}
+
+func TestTrackerAck(t *testing.T) {
+	tracker := NewTracker(newMemStore())
+	anomaly := analyzer.Anomaly{JobID: "job-1", Resource: "res-1", Date: "2026-07-26", Reasons: []string{"Size Spike"}}
+	fp := Fingerprint(anomaly)
+
+	if err := tracker.Ack(fp, time.Now().Add(time.Hour)); err == nil {
+		t.Errorf("Ack() on a fingerprint with no recorded state returned no error, want one")
+	}
+
+	if _, err := tracker.Classify([]analyzer.Anomaly{anomaly}); err != nil {
+		t.Fatalf("Classify() error: %v", err)
+	}
+
+	if err := tracker.Ack(fp, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Ack() error: %v", err)
+	}
+
+	classified, err := tracker.Classify([]analyzer.Anomaly{anomaly})
+	if err != nil {
+		t.Fatalf("Classify() after ack error: %v", err)
+	}
+	if len(classified) != 1 || !classified[0].Acked {
+		t.Fatalf("Classify() after an unexpired ack = %+v, want Acked=true", classified)
+	}
+}
@@ -0,0 +1,187 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// Store persists the fingerprint->AnomalyState map across Analyze runs (and
+// process restarts), so Tracker.Classify sees the same history every call
+// rather than starting fresh. FileStore, GCSStore and FirestoreStore are
+// the built-in implementations; callers can supply their own for other
+// backends.
+type Store interface {
+	Load() (map[string]AnomalyState, error)
+	Save(states map[string]AnomalyState) error
+}
+
+// FileStore persists anomaly state as a single local JSON file -- the
+// simplest Store, for single-instance deployments. GCSStore/FirestoreStore
+// exist for deployments that need state shared across replicas.
+type FileStore struct {
+	Path string
+}
+
+// Load reads the state file at Path, returning an empty map if it doesn't
+// exist yet.
+func (f *FileStore) Load() (map[string]AnomalyState, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]AnomalyState{}, nil
+		}
+		return nil, fmt.Errorf("failed to read anomaly state file %s: %w", f.Path, err)
+	}
+
+	var states map[string]AnomalyState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("failed to parse anomaly state file %s: %w", f.Path, err)
+	}
+	return states, nil
+}
+
+// Save writes states to Path atomically (write to a temp file, then
+// rename), creating parent directories as needed.
+func (f *FileStore) Save(states map[string]AnomalyState) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create anomaly state dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal anomaly state: %w", err)
+	}
+
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write anomaly state: %w", err)
+	}
+	return os.Rename(tmp, f.Path)
+}
+
+// GCSStore persists anomaly state as a single JSON object in a GCS bucket,
+// for deployments that run multiple replicas against shared state without
+// standing up Firestore.
+type GCSStore struct {
+	Bucket string
+	Object string
+}
+
+// Load reads and parses the state object, returning an empty map if it
+// doesn't exist yet.
+func (s *GCSStore) Load() (map[string]AnomalyState, error) {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+	defer client.Close()
+
+	r, err := client.Bucket(s.Bucket).Object(s.Object).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return map[string]AnomalyState{}, nil
+		}
+		return nil, fmt.Errorf("failed to read gs://%s/%s: %w", s.Bucket, s.Object, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gs://%s/%s: %w", s.Bucket, s.Object, err)
+	}
+
+	var states map[string]AnomalyState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("failed to parse gs://%s/%s: %w", s.Bucket, s.Object, err)
+	}
+	return states, nil
+}
+
+// Save overwrites the state object with states.
+func (s *GCSStore) Save(states map[string]AnomalyState) error {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create GCS client: %w", err)
+	}
+	defer client.Close()
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal anomaly state: %w", err)
+	}
+
+	w := client.Bucket(s.Bucket).Object(s.Object).NewWriter(ctx)
+	w.ContentType = "application/json"
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write gs://%s/%s: %w", s.Bucket, s.Object, err)
+	}
+	return w.Close()
+}
+
+// FirestoreStore persists each fingerprint's AnomalyState as a document in
+// a Firestore collection, for deployments that want per-fingerprint reads
+// and writes instead of rewriting one shared blob on every Analyze pass.
+type FirestoreStore struct {
+	ProjectID  string
+	Collection string
+}
+
+// Load reads every document in Collection into a fingerprint->AnomalyState
+// map.
+func (s *FirestoreStore) Load() (map[string]AnomalyState, error) {
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx, s.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Firestore client: %w", err)
+	}
+	defer client.Close()
+
+	states := make(map[string]AnomalyState)
+	iter := client.Collection(s.Collection).Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list firestore collection %s: %w", s.Collection, err)
+		}
+
+		var st AnomalyState
+		if err := doc.DataTo(&st); err != nil {
+			return nil, fmt.Errorf("failed to decode firestore doc %s: %w", doc.Ref.ID, err)
+		}
+		states[doc.Ref.ID] = st
+	}
+	return states, nil
+}
+
+// Save upserts every entry in states as a document keyed by fingerprint.
+func (s *FirestoreStore) Save(states map[string]AnomalyState) error {
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx, s.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to create Firestore client: %w", err)
+	}
+	defer client.Close()
+
+	coll := client.Collection(s.Collection)
+	for fingerprint, st := range states {
+		if _, err := coll.Doc(fingerprint).Set(ctx, st); err != nil {
+			return fmt.Errorf("failed to save firestore doc %s: %w", fingerprint, err)
+		}
+	}
+	return nil
+}